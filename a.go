@@ -1,26 +1,39 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"text/template"
 	"time"
 
-	"github.com/jaytaylor/html2text"
+	"edgarv2/edgar"
 	"golang.org/x/time/rate"
 )
 
 // ──────────────────────────────────────────────────────────────────────────────
 // Everforest  Palette
 // ──────────────────────────────────────────────────────────────────────────────
-const (
+var (
 	reset       = "\033[0m"
 	bold        = "\033[1m"
 	dim         = "\033[2m"
@@ -31,82 +44,719 @@ const (
 	bgGray      = "\033[38;5;243m" // Warm gray
 )
 
-type Company struct {
-	CIK    int    `json:"cik_str"`
-	Ticker string `json:"ticker"`
+// Ticker outcomes, from best to worst.
+const (
+	statusOK        = "ok"
+	statusNoFilings = "no_filings" // resolved, but nothing matched the filters
+	statusNotFound  = "not_found"  // no such ticker, CIK or company name
+	statusError     = "error"      // lookup, listing or download errors
+	statusCanceled  = "canceled"
+)
+
+// Exit statuses, for scripts. A run with several outcomes exits with the
+// highest.
+const (
+	exitOK         = 0 // everything downloaded, skipped or already present
+	exitFailed     = 1 // some filings failed, or the run was interrupted
+	exitUnresolved = 2 // a ticker, CIK or company name could not be resolved
+	exitSetup      = 3 // bad flags or arguments, or EDGAR could not be reached
+)
+
+// TickerResult is the outcome of processing a single ticker.
+type TickerResult struct {
+	Ticker     string `json:"ticker"`
+	Status     string `json:"status"`
+	CIK        string `json:"cik,omitempty"`
+	Processed  int    `json:"processed"`
+	Skipped    int    `json:"skipped"`
+	Failed     int    `json:"failed"`
+	Bytes      int64  `json:"bytes"`
+	TooLarge   int    `json:"too_large"`
+	TimedOut   int    `json:"timed_out,omitempty"`
+	Canceled   int    `json:"canceled,omitempty"`
+	Exhibits   int    `json:"exhibits,omitempty"`
+	Amendments int    `json:"amendments,omitempty"`
+	Superseded int    `json:"superseded,omitempty"`
+	Unresolved bool   `json:"unresolved,omitempty"`
+	Error      string `json:"error,omitempty"`
+	cause      error
+
+	// -validate tallies.
+	Reachable   int `json:"reachable,omitempty"`
+	Unreachable int `json:"unreachable,omitempty"`
+
+	LimiterWait float64 `json:"limiter_wait_seconds"`
+	RateLimited int     `json:"rate_limited"`
+
+	Forms   map[string]int `json:"forms,omitempty"`
+	Filings []FilingResult `json:"filings,omitempty"`
 }
 
-type TickerMap map[string]Company
+// FilingResult records what happened to one filing.
+type FilingResult struct {
+	Form      string `json:"form"`
+	Date      string `json:"date"`
+	Accession string `json:"accession"`
+	Accepted  string `json:"accepted,omitempty"`
+	Path      string `json:"path,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Status    string `json:"status"`
+	Bytes     int64  `json:"bytes"`
+	Error     string `json:"error,omitempty"`
 
-type Submissions struct {
-	Filings struct {
-		Recent struct {
-			AccessionNumber []string `json:"accessionNumber"`
-			FilingDate      []string `json:"filingDate"`
-			Form            []string `json:"form"`
-			PrimaryDoc      []string `json:"primaryDocument"`
-		} `json:"recent"`
-	} `json:"filings"`
+	SupersededBy string `json:"superseded_by,omitempty"`
 }
 
-type item struct {
-	formType string
-	accNum   string
-	docName  string
-	dateStr  string
+// RunSummary aggregates the TickerResults of a whole run.
+type RunSummary struct {
+	Tickers    int     `json:"tickers"`
+	Processed  int     `json:"processed"`
+	Skipped    int     `json:"skipped"`
+	Failed     int     `json:"failed"`
+	Bytes      int64   `json:"bytes"`
+	TooLarge   int     `json:"too_large"`
+	TimedOut   int     `json:"timed_out"`
+	Canceled   int     `json:"canceled"`
+	Exhibits   int     `json:"exhibits"`
+	Unresolved int     `json:"unresolved"`
+	NoFilings  int     `json:"no_filings"`
+	Elapsed    float64 `json:"elapsed_seconds"`
+
+	Reachable   int `json:"reachable,omitempty"`
+	Unreachable int `json:"unreachable,omitempty"`
+
+	// Rate-limit counters of the whole run, ticker lookups included.
+	Requests    int     `json:"requests"`
+	LimiterWait float64 `json:"limiter_wait_seconds"`
+	RateLimited int     `json:"rate_limited"`
+}
+
+func summarize(results []TickerResult, elapsed time.Duration) RunSummary {
+	s := RunSummary{Tickers: len(results), Elapsed: elapsed.Seconds()}
+	for _, r := range results {
+		s.Processed += r.Processed
+		s.Skipped += r.Skipped
+		s.Failed += r.Failed
+		s.Bytes += r.Bytes
+		s.TooLarge += r.TooLarge
+		s.TimedOut += r.TimedOut
+		s.Canceled += r.Canceled
+		s.Exhibits += r.Exhibits
+		s.Reachable += r.Reachable
+		s.Unreachable += r.Unreachable
+		if r.Unresolved {
+			s.Unresolved++
+		}
+		if r.Status == statusNoFilings {
+			s.NoFilings++
+		}
+	}
+	return s
+}
+
+// Report is the -json document for a whole run, keyed by ticker.
+type Report struct {
+	Tickers map[string]TickerResult `json:"tickers"`
+	Summary RunSummary              `json:"summary"`
 }
 
 const (
-	UserAgent         = "Company SysAdmin contact@yahoo.com"
-	MaxFilesToFetch   = 10
-	barWidth          = 20
-	MaxRetries        = 5
-	DefaultRetryDelay = 5 * time.Second
+	MaxFilesToFetch = 10
+	barWidth        = 20
 )
 
+// options holds the command-line configuration for a run.
+type options struct {
+	summaryJSON   bool
+	jsonLines     bool
+	jsonReport    bool
+	interactive   bool
+	resolve       hostOverrides
+	proxy         string
+	insecure      bool
+	quietUnless   bool
+	financials    bool
+	section       string
+	keepLinks     bool
+	keepTables    bool
+	collapseSpace bool
+	keepHTML      bool
+	nameTemplate  string
+	groupByYear   bool
+	stateFile     string
+	config        string
+	shuffle       bool
+	seed          int64
+	logFile       string
+	preferAmend   bool
+	maxDocBytes   int64
+	maxFileBytes  int64
+	forms         formSet
+	ciks          string
+	descContains  string
+	limit         int
+	from, to      time.Time
+	format        string
+	concurrency   int
+	exhibits      bool
+	rps           float64
+	userAgent     string
+	timeout       time.Duration
+	outputDir     string
+	amendments    bool
+	verbose       bool
+	quiet         bool
+	noColor       bool
+	dryRun        bool
+	validate      bool
+	sinceLast     bool
+	listForms     bool
+	complete      bool
+	years         []int
+	oldestFirst   bool
+	newestFirst   bool
+	tickersFile   string
+	offline       bool
+	maxRetryAfter time.Duration
+	httpTimeout   time.Duration
+	fileTimeout   time.Duration
+	maxRetries    int
+
+	tickersTTL     time.Duration
+	refreshTickers bool
+
+	tickerConcurrency int
+
+	// -sec-url, -data-url and -search-url; empty means the SEC hosts.
+	secURL, dataURL, searchURL string
+}
+
 var (
-	httpClient = &http.Client{Timeout: 45 * time.Second}
-	limiter    = rate.NewLimiter(rate.Limit(8), 8)
+	opts options
+
+	client = edgar.NewClient()
+
+	// rng orders the download queue under -shuffle; nil otherwise.
+	rng *rand.Rand
+
+	// nameTemplate is -filename-template, parsed once up front.
+	nameTemplate *template.Template
+
+	// state is the -state-file of filings saved by any run, or nil.
+	state *edgar.State
+
+	// logger records requests and per-filing results for troubleshooting.
+	logger = slog.New(slog.DiscardHandler)
+
+	// out receives all human-facing output; machine-readable modes discard it.
+	out   io.Writer = os.Stdout
+	stdin           = bufio.NewReader(os.Stdin)
 )
 
-func parseRetryAfter(val string) time.Duration {
-	if val == "" {
-		return 0
+// teeHandler sends each record to every handler, so -log-file and -verbose
+// can be combined.
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	tee := make(teeHandler, len(t))
+	for i, h := range t {
+		tee[i] = h.WithAttrs(attrs)
+	}
+	return tee
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	tee := make(teeHandler, len(t))
+	for i, h := range t {
+		tee[i] = h.WithGroup(name)
 	}
-	if secs, err := strconv.Atoi(val); err == nil && secs > 0 {
-		return time.Duration(secs) * time.Second
+	return tee
+}
+
+// formSet implements flag.Value for "-forms 8-K,10-K,S-1". Form types are
+// upper-cased since EDGAR is not always consistent about case.
+type formSet map[string]bool
+
+func (f formSet) String() string {
+	return strings.Join(f.list(), ",")
+}
+
+func (f formSet) Set(v string) error {
+	for _, form := range strings.Split(v, ",") {
+		if form = strings.ToUpper(strings.TrimSpace(form)); form != "" {
+			f[form] = true
+		}
 	}
-	return 0
+	return nil
+}
+
+func (f formSet) list() []string {
+	forms := make([]string, 0, len(f))
+	for form := range f {
+		forms = append(forms, form)
+	}
+	sort.Strings(forms)
+	return forms
+}
+
+// formGroups are the -form-group presets for common bundles of forms.
+var formGroups = map[string][]string{
+	"annual":    {"10-K", "10-K/A", "20-F", "20-F/A", "40-F", "40-F/A"},
+	"quarterly": {"10-Q", "10-Q/A"},
+	"interim":   {"10-Q", "10-Q/A", "6-K", "6-K/A"},
+	"insider":   {"3", "4", "5"},
+	"proxy":     {"DEF 14A", "DEFA14A"},
+	"events":    {"8-K"},
+	"fund":      {"N-CSR", "N-CSR/A", "N-CSRS", "N-CSRS/A", "NPORT-P", "NPORT-P/A"},
 }
 
-func doRateLimitedRequest(req *http.Request) (*http.Response, error) {
-	if err := limiter.Wait(context.Background()); err != nil {
-		return nil, fmt.Errorf("rate limiter: %w", err)
+// addFormGroups adds the forms of each comma-separated preset name to
+// forms, next to whatever -forms lists.
+func addFormGroups(forms formSet) func(string) error {
+	return func(v string) error {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			group, ok := formGroups[name]
+			if !ok {
+				names := make([]string, 0, len(formGroups))
+				for n := range formGroups {
+					names = append(names, n)
+				}
+				sort.Strings(names)
+				return fmt.Errorf("unknown form group %q (want %s)", name, strings.Join(names, ", "))
+			}
+			forms.Set(strings.Join(group, ","))
+		}
+		return nil
 	}
-	for attempt := 0; attempt < MaxRetries; attempt++ {
-		resp, err := httpClient.Do(req)
+}
+
+// dateFlag parses a YYYY-MM-DD flag value, so malformed dates are rejected
+// up front instead of silently matching nothing.
+func dateFlag(dst *time.Time) func(string) error {
+	return func(v string) error {
+		t, err := time.Parse(time.DateOnly, v)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("want YYYY-MM-DD, got %q", v)
+		}
+		*dst = t
+		return nil
+	}
+}
+
+// baseURLFlag accepts an http(s) URL for one of the client's base URLs,
+// without a trailing slash.
+func baseURLFlag(dst *string) func(string) error {
+	return func(v string) error {
+		u, err := url.Parse(v)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("want an http or https URL, got %q", v)
+		}
+		*dst = strings.TrimRight(v, "/")
+		return nil
+	}
+}
+
+// yearsFlag parses a comma-separated list of years, replacing any earlier
+// value.
+func yearsFlag(dst *[]int) func(string) error {
+	return func(v string) error {
+		var years []int
+		for _, s := range strings.Split(v, ",") {
+			y, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil || y < 1993 || y > 9999 {
+				return fmt.Errorf("want years like 2021,2022, got %q", v)
+			}
+			years = append(years, y)
 		}
-		if resp.StatusCode == http.StatusTooManyRequests {
-			delay := parseRetryAfter(resp.Header.Get("Retry-After"))
-			if delay <= 0 {
-				delay = DefaultRetryDelay * time.Duration(attempt+1)
+		*dst = years
+		return nil
+	}
+}
+
+// sizeFlag parses a byte count with an optional KB, MB or GB suffix
+// (powers of 1024), e.g. "100MB".
+func sizeFlag(dst *int64) func(string) error {
+	return func(v string) error {
+		num, mult := strings.ToUpper(strings.TrimSpace(v)), int64(1)
+		for _, u := range []struct {
+			suffix string
+			mult   int64
+		}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+			if strings.HasSuffix(num, u.suffix) {
+				num, mult = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.mult
+				break
 			}
-			resp.Body.Close()
-			time.Sleep(delay)
-			continue
 		}
-		return resp, nil
+		n, err := strconv.ParseFloat(num, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("want a size like 500KB or 100MB, got %q", v)
+		}
+		*dst = int64(n * float64(mult))
+		return nil
+	}
+}
+
+// hostOverrides implements flag.Value for repeatable "-resolve host:ip" pins.
+type hostOverrides map[string]string
+
+func (h hostOverrides) String() string {
+	pins := make([]string, 0, len(h))
+	for host, ip := range h {
+		pins = append(pins, host+":"+ip)
+	}
+	sort.Strings(pins)
+	return strings.Join(pins, ",")
+}
+
+func (h hostOverrides) Set(v string) error {
+	host, ip, ok := strings.Cut(v, ":")
+	if !ok || host == "" {
+		return fmt.Errorf("want host:ip, got %q", v)
+	}
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("invalid IP address %q", ip)
+	}
+	h[strings.ToLower(host)] = ip
+	return nil
+}
+
+// userAgent prefers -user-agent, then $EDGAR_USER_AGENT, then the library's
+// placeholder.
+func userAgent() string {
+	if ua := strings.TrimSpace(opts.userAgent); ua != "" {
+		return ua
+	}
+	if ua := strings.TrimSpace(os.Getenv("EDGAR_USER_AGENT")); ua != "" {
+		return ua
+	}
+	return edgar.DefaultUserAgent
+}
+
+// fetchOptions maps the filter flags onto the library's FetchOptions.
+// -interactive lists every match, whatever -limit says: the user picks from
+// the list instead.
+func fetchOptions() edgar.FetchOptions {
+	fo := edgar.FetchOptions{
+		Forms:             opts.forms.list(),
+		From:              opts.from,
+		To:                opts.to,
+		Limit:             opts.limit,
+		IncludeAmendments: opts.amendments,
+		PreferAmendment:   opts.preferAmend,
+		Years:             opts.years,
+		OldestFirst:       opts.oldestFirst,
+		DescContains:      opts.descContains,
+	}
+	if opts.interactive {
+		fo.Limit = 0
+	}
+	return fo
+}
+
+func downloadOptions() edgar.DownloadOptions {
+	return edgar.DownloadOptions{
+		Format:      opts.format,
+		MaxDocBytes: opts.maxDocBytes,
+		Financials:  opts.financials,
+		Section:     opts.section,
+
+		NameTemplate: nameTemplate,
+		Text:         edgar.TextOptions{KeepLinks: opts.keepLinks, KeepTables: opts.keepTables, CollapseWhitespace: opts.collapseSpace},
+		GroupByYear:  opts.groupByYear,
+		KeepHTML:     opts.keepHTML,
+		State:        state,
 	}
-	return nil, fmt.Errorf("max retries exceeded")
 }
 
 func main() {
+	flag.BoolVar(&opts.summaryJSON, "summary-json", false, "print only a JSON object with run totals")
+	flag.BoolVar(&opts.jsonReport, "json", false, "print a single JSON report of all tickers instead of the UI")
+	flag.BoolVar(&opts.jsonLines, "jsonl", false, "print one JSON object per filing as it finishes instead of the UI")
+	flag.BoolVar(&opts.interactive, "interactive", false, "pick filings to download from a numbered list (lists only when not a TTY)")
+	flag.BoolVar(&opts.quietUnless, "quiet-unless-changed", false, "print output only if new filings were downloaded")
+	flag.BoolVar(&opts.shuffle, "shuffle", false, "download the filings of all tickers from one randomly ordered queue to spread load")
+	flag.Int64Var(&opts.seed, "seed", 0, "seed for -shuffle (default: time-based)")
+	flag.DurationVar(&opts.httpTimeout, "http-timeout", edgar.DefaultHTTPTimeout, "per-request timeout, e.g. 90s; a request that times out is retried")
+	flag.DurationVar(&opts.fileTimeout, "per-file-timeout", 0, "time limit for each filing, exhibits included, e.g. 5m; a filing that runs over is retried, then reported as timed out (0 = none)")
+	flag.IntVar(&opts.maxRetries, "max-total-retries", 0, "abort the run once this many retries have been made across all requests (0 = no cap)")
+	flag.DurationVar(&opts.maxRetryAfter, "max-retry-after", 5*time.Minute, "fail instead of waiting when the server's Retry-After is longer (0 = always wait)")
+	flag.StringVar(&opts.tickersFile, "tickers-file", "", "read tickers from this file, one per line (# starts a comment)")
+	flag.BoolVar(&opts.offline, "offline", false, "no network: re-convert filings saved by earlier runs (-format html or -exhibits)")
+	flag.BoolVar(&opts.sinceLast, "since-last", false, "only fetch filings newer than the newest one already saved for the ticker")
+	flag.BoolVar(&opts.listForms, "list-forms", false, "print how many filings of each form type the tickers have, then exit without downloading")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "list the filings that would be downloaded, with their URLs, and download nothing")
+	flag.BoolVar(&opts.validate, "validate", false, "check that each filing's document URL answers 200 (HEAD requests) and download nothing")
+	flag.BoolVar(&opts.noColor, "no-color", false, "disable ANSI colors (also set by NO_COLOR or when stdout is not a terminal)")
+	flag.BoolVar(&opts.quiet, "quiet", false, "no banner or progress; print only errors to stderr; the exit status tells how the run went")
+	flag.BoolVar(&opts.verbose, "verbose", false, "log every request, status, retry and the resolved CIK to stderr")
+	flag.StringVar(&opts.logFile, "log-file", "", "append structured (JSON) request logs and one result line per filing to this file")
+	flag.BoolVar(&opts.amendments, "include-amendments", false, "also fetch the /A amendments of the selected forms")
+	flag.BoolVar(&opts.preferAmend, "prefer-amendment", false, "include 10-K/A and 10-Q/A, keeping only the latest version per report period")
+	flag.Int64Var(&opts.maxDocBytes, "max-doc-bytes", 0, "skip documents larger than this many bytes (0 = no limit)")
+	flag.Func("max-file-size", "skip any file larger than this, exhibits and -complete included, e.g. 100MB (default no limit)", sizeFlag(&opts.maxFileBytes))
+	flag.StringVar(&opts.nameTemplate, "filename-template", "", "Go template for file names without extension, e.g. {{.Ticker}}_{{.Form}}_{{.Period}}")
+	flag.StringVar(&opts.stateFile, "state-file", "", "JSON file recording every filing saved, by CIK and accession; filings in it are skipped whatever the output directory")
+	flag.BoolVar(&opts.groupByYear, "group-by-year", false, "save each filing in a YYYY subfolder of the ticker folder (report year, else filing year)")
+	flag.StringVar(&opts.section, "section", "", "keep only this item of the text, e.g. 7 (MD&A) or 1A (Risk Factors)")
+	flag.BoolVar(&opts.keepLinks, "keep-links", false, "keep link URLs in the text output")
+	flag.BoolVar(&opts.keepTables, "keep-tables", false, "write table rows on their own lines with | between cells")
+	flag.BoolVar(&opts.keepHTML, "keep-html", false, "also save the original .htm next to each text or pdf file, from the same download")
+	flag.BoolVar(&opts.collapseSpace, "collapse-whitespace", false, "trim trailing whitespace from every line of the text, for cleaner diffs")
+	flag.BoolVar(&opts.financials, "financials", false, "also write income statement, balance sheet and cash flow JSON from inline XBRL")
+	flag.IntVar(&opts.limit, "limit", MaxFilesToFetch, "maximum filings per ticker (0 = all available)")
+	flag.BoolVar(&opts.oldestFirst, "oldest-first", false, "process filings in chronological order; with -limit, take the earliest ones")
+	flag.BoolVar(&opts.newestFirst, "newest-first", false, "process the newest filings first (the default)")
+	flag.Func("from", "only filings on or after this date (YYYY-MM-DD)", dateFlag(&opts.from))
+	flag.Func("to", "only filings on or before this date (YYYY-MM-DD)", dateFlag(&opts.to))
+	flag.StringVar(&opts.descContains, "desc-contains", "", "only filings whose primary document description contains this text (case-insensitive)")
+	flag.Func("year", "only filings whose fiscal period (report date) ends in these years, e.g. 2020,2021,2022", yearsFlag(&opts.years))
+	flag.BoolVar(&opts.complete, "complete", false, "save each filing's complete SGML submission (<accession>.txt, all documents) verbatim instead of the primary document")
+	flag.StringVar(&opts.format, "format", "text", "output format: text (converted .txt), html (original .htm) or pdf (converted text as .pdf)")
+	flag.IntVar(&opts.concurrency, "concurrency", 4, "parallel downloads per ticker (requests still share the global rate limit)")
+	flag.IntVar(&opts.tickerConcurrency, "ticker-concurrency", 1, "tickers processed at once; above 1 each ticker prints a summary line instead of live progress")
+	flag.DurationVar(&opts.tickersTTL, "tickers-ttl", 24*time.Hour, "how long the cached ticker list stays fresh")
+	flag.BoolVar(&opts.refreshTickers, "refresh-tickers", false, "re-download the ticker list even if the cache is fresh")
+	flag.BoolVar(&opts.exhibits, "exhibits", false, "also download every document of each filing into a per-filing subdirectory")
+	flag.StringVar(&opts.userAgent, "user-agent", "", "User-Agent with your contact details, as SEC requires (default $EDGAR_USER_AGENT)")
+	flag.StringVar(&opts.outputDir, "output-dir", ".", "base directory for the per-ticker filings_TICKER folders")
+	flag.DurationVar(&opts.timeout, "timeout", 0, "stop the whole run after this long, e.g. 30m (0 = no deadline)")
+	flag.Float64Var(&opts.rps, "rps", 8, "requests per second to EDGAR; values above SEC's limit of 10 are lowered to 10 with a warning")
+	flag.StringVar(&opts.ciks, "cik", "", "comma-separated CIKs to fetch directly, bypassing the ticker lookup")
+	opts.forms = formSet{}
+	flag.Var(opts.forms, "forms", "comma-separated form types to fetch (default 10-K,10-Q; see -form-group for foreign issuers and funds)")
+	flag.Func("form-group", "fetch a preset bundle of forms: annual, quarterly, interim, insider, proxy, events or fund (comma-separated; combines with -forms)", addFormGroups(opts.forms))
+	opts.resolve = hostOverrides{}
+	flag.Var(opts.resolve, "resolve", "pin a host to an IP, as host:ip (repeatable)")
+	flag.Func("sec-url", "base URL of the filing archives and ticker lists, e.g. an internal EDGAR mirror (default "+edgar.DefaultSECBaseURL+")", baseURLFlag(&opts.secURL))
+	flag.Func("data-url", "base URL of the submissions and XBRL APIs (default "+edgar.DefaultDataBaseURL+")", baseURLFlag(&opts.dataURL))
+	flag.Func("search-url", "base URL of full-text search (default "+edgar.DefaultSearchBaseURL+")", baseURLFlag(&opts.searchURL))
+	flag.StringVar(&opts.proxy, "proxy", "", "proxy URL, e.g. http://proxy:8080 or socks5://127.0.0.1:1080 (default: HTTP_PROXY/HTTPS_PROXY)")
+	flag.BoolVar(&opts.insecure, "insecure", false, "skip TLS certificate verification, for proxies that intercept TLS")
+	flag.StringVar(&opts.config, "config", "", "JSON file of default flag values (default config.json in the user config dir's edgarv2 folder)")
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	parseFlags(flag.CommandLine, os.Args[1:])
+
+	args := flag.Args()
+	cmd, isCmd := commands[flag.Arg(0)]
+	if isCmd {
+		args = cmd.parse(flag.Arg(0), args[1:])
+	}
+	if err := loadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "-config: %v\n", err)
+		os.Exit(exitSetup)
+	}
+	if opts.noColor || os.Getenv("NO_COLOR") != "" || !isTerminal(os.Stdout) {
+		disableColors()
+	}
+
+	if !opts.from.IsZero() && !opts.to.IsZero() && opts.to.Before(opts.from) {
+		fmt.Fprintln(os.Stderr, softRed+"-to is before -from"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.format != "text" && opts.format != "html" && opts.format != "pdf" {
+		fmt.Fprintf(os.Stderr, "%sunknown -format %q (want text, html or pdf)%s\n", softRed, opts.format, reset)
+		os.Exit(exitSetup)
+	}
+	if opts.complete {
+		if opts.format != "text" || opts.section != "" || opts.financials || opts.keepLinks || opts.keepTables || opts.collapseSpace || opts.keepHTML || opts.offline {
+			fmt.Fprintln(os.Stderr, softRed+"-complete saves the submission verbatim and cannot be combined with -format, -section, -financials, -keep-links, -keep-tables, -collapse-whitespace, -keep-html or -offline"+reset)
+			os.Exit(exitSetup)
+		}
+		opts.format = "complete"
+	}
+	if (opts.keepLinks || opts.keepTables || opts.collapseSpace || opts.keepHTML) && opts.format == "html" {
+		fmt.Fprintln(os.Stderr, softRed+"-keep-links, -keep-tables, -collapse-whitespace and -keep-html go with the text and pdf output, not -format html"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.nameTemplate != "" {
+		t, err := edgar.ParseNameTemplate(opts.nameTemplate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s-filename-template: %v%s\n", softRed, err, reset)
+			os.Exit(exitSetup)
+		}
+		nameTemplate = t
+	}
+	if opts.stateFile != "" {
+		st, err := client.OpenState(opts.stateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s-state-file: %v%s\n", softRed, err, reset)
+			os.Exit(exitSetup)
+		}
+		state = st
+	}
+	if opts.section != "" {
+		item, err := edgar.ParseItem(opts.section)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s-section: %v%s\n", softRed, err, reset)
+			os.Exit(exitSetup)
+		}
+		if opts.format == "html" {
+			fmt.Fprintln(os.Stderr, softRed+"-section works on text and pdf output, not -format html"+reset)
+			os.Exit(exitSetup)
+		}
+		opts.section = item
+	}
+	if opts.tickerConcurrency < 1 {
+		fmt.Fprintln(os.Stderr, softRed+"-ticker-concurrency must be at least 1"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.tickerConcurrency > 1 && opts.shuffle {
+		fmt.Fprintln(os.Stderr, softRed+"-shuffle downloads all tickers from one queue and cannot be combined with -ticker-concurrency"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.tickerConcurrency > 1 && opts.interactive {
+		fmt.Fprintln(os.Stderr, softRed+"-interactive asks per ticker and cannot be combined with -ticker-concurrency"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.concurrency < 1 {
+		fmt.Fprintln(os.Stderr, softRed+"-concurrency must be at least 1"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.rps <= 0 {
+		fmt.Fprintf(os.Stderr, "%s-rps must be above 0, got %g%s\n", softRed, opts.rps, reset)
+		os.Exit(exitSetup)
+	}
+	if opts.rps > 10 {
+		fmt.Fprintf(os.Stderr, "%s-rps %g is above SEC's fair access limit; using 10.%s\n", earthYellow, opts.rps, reset)
+		opts.rps = 10
+	}
+	if opts.maxRetries < 0 {
+		fmt.Fprintln(os.Stderr, softRed+"-max-total-retries must be 0 or more"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.httpTimeout <= 0 {
+		fmt.Fprintln(os.Stderr, softRed+"-http-timeout must be positive"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.offline && opts.sinceLast {
+		fmt.Fprintln(os.Stderr, softRed+"-since-last looks for new filings online and cannot be combined with -offline"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.offline && opts.exhibits {
+		fmt.Fprintln(os.Stderr, softRed+"-exhibits needs the network and cannot be combined with -offline"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.limit < 0 {
+		fmt.Fprintln(os.Stderr, softRed+"-limit must be 0 (unlimited) or positive"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.oldestFirst && opts.newestFirst {
+		fmt.Fprintln(os.Stderr, softRed+"-oldest-first and -newest-first cannot be combined"+reset)
+		os.Exit(exitSetup)
+	}
+	var proxyURL *url.URL
+	if opts.proxy != "" {
+		u, err := url.Parse(opts.proxy)
+		if err != nil || u.Host == "" {
+			fmt.Fprintf(os.Stderr, "%s-proxy: want a URL such as http://proxy:8080, got %q%s\n", softRed, opts.proxy, reset)
+			os.Exit(exitSetup)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			fmt.Fprintf(os.Stderr, "%s-proxy: unsupported scheme %q (want http, https or socks5)%s\n", softRed, u.Scheme, reset)
+			os.Exit(exitSetup)
+		}
+		proxyURL = u
+	}
+
+	if opts.validate && opts.dryRun {
+		fmt.Fprintln(os.Stderr, softRed+"-validate and -dry-run both download nothing; pick one"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.jsonLines && (opts.jsonReport || opts.summaryJSON) {
+		fmt.Fprintln(os.Stderr, softRed+"-jsonl streams to stdout and cannot be combined with -json or -summary-json"+reset)
+		os.Exit(exitSetup)
+	}
+	if opts.summaryJSON || opts.jsonReport || opts.jsonLines || opts.quiet {
+		out = io.Discard
+	}
+	transport := edgar.NewTransport(opts.resolve)
+	if opts.proxy != "" {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if opts.insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		if !opts.quiet {
+			fmt.Fprintln(os.Stderr, earthYellow+"-insecure: TLS certificates are not verified."+reset)
+		}
+	}
+	client.HTTP.Transport = transport
+	var handlers teeHandler
+	if opts.logFile != "" {
+		f, err := os.OpenFile(opts.logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sCannot open log file: %v%s\n", softRed, err, reset)
+			os.Exit(exitSetup)
+		}
+		defer f.Close()
+		handlers = append(handlers, slog.NewJSONHandler(f, nil))
+	}
+	if opts.verbose {
+		handlers = append(handlers, slog.NewTextHandler(os.Stderr, nil))
+	}
+	if len(handlers) > 0 {
+		logger = slog.New(handlers)
+		client.Logger = logger
+	}
+	client.Limiter = rate.NewLimiter(rate.Limit(opts.rps), max(1, int(opts.rps)))
+	client.UserAgent = userAgent()
+	if client.UserAgent == edgar.DefaultUserAgent && !opts.quiet {
+		fmt.Fprintln(os.Stderr, earthYellow+"Using the placeholder User-Agent; set -user-agent or EDGAR_USER_AGENT to \"Name email@example.com\" as SEC requires."+reset)
+	}
+	client.MaxRetryAfter = opts.maxRetryAfter
+	client.HTTP.Timeout = opts.httpTimeout
+	client.MaxTotalRetries = opts.maxRetries
+	client.MaxFileBytes = opts.maxFileBytes
+	client.TickersTTL = opts.tickersTTL
+	client.RefreshTickers = opts.refreshTickers
+	client.Offline = opts.offline
+	client.SECBaseURL, client.DataBaseURL, client.SearchBaseURL = opts.secURL, opts.dataURL, opts.searchURL
+
+	if isCmd {
+		ctx, stop := runContext()
+		code := cmd.run(ctx, args)
+		stop()
+		os.Exit(code)
+	}
+
 	var tickers []string
-	if len(os.Args) > 1 {
-		tickers = os.Args[1:]
-	} else {
+	for _, c := range strings.Split(opts.ciks, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			tickers = append(tickers, "CIK:"+c)
+		}
+	}
+	if opts.tickersFile != "" {
+		fromFile, err := readTickersFile(opts.tickersFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sCannot read tickers file: %v%s\n", softRed, err, reset)
+			os.Exit(exitSetup)
+		}
+		tickers = append(tickers, fromFile...)
+	}
+	if len(args) > 0 {
+		tickers = append(tickers, args...)
+	} else if len(tickers) == 0 {
 		var ticker string
 		fmt.Print(aquaBlue + bold + "Enter Ticker (e.g. MSFT): " + reset)
 		fmt.Scanln(&ticker)
@@ -115,184 +765,899 @@ func main() {
 		}
 	}
 
+	tickers = normalizeTickers(tickers)
 	if len(tickers) == 0 {
-		fmt.Println(softRed + "No ticker provided. Exiting." + reset)
-		return
+		fmt.Fprintln(os.Stderr, softRed+"No ticker provided. Exiting."+reset)
+		os.Exit(exitSetup)
 	}
 
-	fmt.Printf("\n" + forestGreen + bold + "EDGAR v2" + reset + "\n")
+	if opts.shuffle {
+		seed := opts.seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		rng = rand.New(rand.NewSource(seed))
+	}
 
-	for _, ticker := range tickers {
-		t := strings.ToUpper(strings.TrimSpace(ticker))
-		if t == "" {
-			continue
+	if opts.listForms {
+		ctx, stop := runContext()
+		code := listForms(ctx, tickers)
+		stop()
+		os.Exit(code)
+	}
+
+	// Hold the UI back until we know whether anything new arrived.
+	var held *bytes.Buffer
+	if opts.quietUnless && out == os.Stdout {
+		held = &bytes.Buffer{}
+		out = held
+	}
+
+	fmt.Fprint(out, "\n"+forestGreen+bold+"EDGAR v2"+reset+"\n")
+
+	ctx, stop := runContext()
+	defer stop()
+	ctx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+
+	start := time.Now()
+	results := runTickers(ctx, tickers, abort)
+
+	summary := summarize(results, time.Since(start))
+	stats := client.Stats()
+	summary.Requests = stats.Requests
+	summary.LimiterWait = stats.LimiterWait.Seconds()
+	summary.RateLimited = stats.RateLimited
+	if len(results) > 1 {
+		fmt.Fprintf(out, "%sAll tickers:%s ", bold, reset)
+		printRateStats(stats)
+	}
+	logger.Info("run finished", "summary", summary)
+	if ctx.Err() != nil {
+		reason := "Interrupted"
+		switch cause := context.Cause(ctx); {
+		case errors.Is(cause, context.DeadlineExceeded):
+			reason = "Timed out"
+		case errors.Is(cause, edgar.ErrRetryBudget):
+			reason = fmt.Sprintf("EDGAR keeps failing; -max-total-retries %d used up", opts.maxRetries)
+		}
+		w := out
+		if opts.quiet {
+			w = os.Stderr
+		}
+		fmt.Fprintf(w, "\n%s%s after %d of %d ticker(s): %d processed, %d skipped, %d failed, %d canceled.%s\n",
+			softRed, reason, len(results), len(tickers), summary.Processed, summary.Skipped, summary.Failed, summary.Canceled, reset)
+		logger.Warn("run stopped early", "reason", context.Cause(ctx))
+	}
+	if held != nil {
+		// Failures still print in full so cron mails them.
+		if summary.Processed == 0 && summary.Failed == 0 && summary.Unresolved == 0 && ctx.Err() == nil {
+			fmt.Println("No new filings.")
+		} else {
+			os.Stdout.Write(held.Bytes())
 		}
-		fmt.Printf(bgGray+"Ticker: "+aquaBlue+"%s%s%s\n\n", bold, t, reset)
-		processTicker(t)
 	}
+
+	switch {
+	case opts.jsonReport:
+		report := Report{Tickers: make(map[string]TickerResult, len(results)), Summary: summary}
+		for _, r := range results {
+			report.Tickers[r.Ticker] = r
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	case opts.summaryJSON:
+		json.NewEncoder(os.Stdout).Encode(summary)
+	}
+	if opts.quiet || opts.jsonLines {
+		printErrors(results)
+	}
+	code := exitStatus(results)
+	if ctx.Err() != nil {
+		code = max(code, exitFailed)
+	}
+	stop()
+	os.Exit(code)
 }
 
-func processTicker(ticker string) {
-	fmt.Printf(bgGray + "Looking up CIK... " + reset)
-	CIK, err := getCIK(ticker)
-	if err != nil {
-		fmt.Printf("%sFailed: %v%s\n", softRed, err, reset)
-		return
+// normalizeTickers upper-cases and trims the tickers and drops blanks and
+// repeats, keeping the first occurrence. CIKs compare by number, so
+// "320193" and "CIK:0000320193" count as the same company.
+func normalizeTickers(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	tickers := make([]string, 0, len(in))
+	for _, t := range in {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		key := t
+		if cik, ok := parseCIKArg(t); ok {
+			key = "CIK:" + strconv.Itoa(cik)
+		}
+		if t == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tickers = append(tickers, t)
 	}
-	paddedCIK := fmt.Sprintf("%010d", CIK)
-	fmt.Printf("%sOK: %s%s\n", forestGreen, paddedCIK, reset)
+	return tickers
+}
 
-	fmt.Printf(bgGray + "Fetching filings... " + reset)
-	submissions, err := getFilings(paddedCIK)
+// readTickersFile returns the tickers listed in a watchlist file, one per
+// line. Blank lines and anything after a '#' are ignored.
+func readTickersFile(name string) ([]string, error) {
+	f, err := os.Open(name)
 	if err != nil {
-		fmt.Printf("%sError: %v%s\n", softRed, err, reset)
-		return
+		return nil, err
 	}
-	fmt.Printf("%sOK%s\n", forestGreen, reset)
+	defer f.Close()
 
-	var items []item
-	for i, formType := range submissions.Filings.Recent.Form {
-		if (formType == "10-K" || formType == "10-Q") && len(items) < MaxFilesToFetch {
-			items = append(items, item{
-				formType: formType,
-				accNum:   submissions.Filings.Recent.AccessionNumber[i],
-				docName:  submissions.Filings.Recent.PrimaryDoc[i],
-				dateStr:  submissions.Filings.Recent.FilingDate[i],
-			})
+	var tickers []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			tickers = append(tickers, line)
 		}
 	}
+	return tickers, sc.Err()
+}
 
-	if len(items) == 0 {
-		fmt.Println(earthYellow + "No recent 10-K/Q found." + reset)
-		return
+// runContext is canceled by Ctrl-C or -timeout: new work stops, in-flight
+// requests are aborted and whatever finished is still reported.
+func runContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if opts.timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	return ctx, func() { cancel(); stop() }
+}
+
+// command is a subcommand such as "search". Global flags are accepted after
+// its name as well, next to its own.
+type command struct {
+	usage string
+	flags func(fs *flag.FlagSet)
+	run   func(ctx context.Context, args []string) int
+}
+
+var commands = map[string]*command{
+	"search": {usage: "search [flags] <query>", flags: searchFlags, run: runSearch},
+	"facts":  {usage: "facts (-concept | -latest) us-gaap:Revenues [-csv] <ticker|CIK>...", flags: factsFlags, run: runFacts},
+	"frames": {usage: "frames -concept us-gaap:Revenues -year 2023 [-unit USD] [<ticker|CIK>...]", flags: framesFlags, run: runFrames},
+	"get":    {usage: "get -accession 0000320193-24-000123 [-doc name.htm] [-stdout] (-cik 320193 | <ticker>)", flags: getFlags, run: runGet},
+	"grep":   {usage: "grep [-ignore-case] [-fixed] [-context N] <pattern> [dir|file]...", flags: grepFlags, run: runGrep},
+	"whois":  {usage: "whois (-cik 320193 | <CIK>...)", run: runWhois},
+}
+
+// parse reads the command's flags and returns its positional arguments.
+func (c *command) parse(name string, args []string) []string {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s\n", os.Args[0], c.usage)
+		fs.PrintDefaults()
+	}
+	flag.VisitAll(func(f *flag.Flag) { fs.Var(f.Value, f.Name, f.Usage) })
+	if c.flags != nil {
+		c.flags(fs)
 	}
+	parseFlags(fs, args)
+	return fs.Args()
+}
 
-	downloadDir := "./filings_" + ticker
-	os.MkdirAll(downloadDir, 0755)
+// parseFlags parses args into fs, exiting with exitSetup on a bad flag (the
+// flag package would use 2) and with exitOK after -h.
+func parseFlags(fs *flag.FlagSet, args []string) {
+	switch err := fs.Parse(args); {
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(exitOK)
+	case err != nil:
+		os.Exit(exitSetup)
+	}
+	fs.Visit(func(f *flag.Flag) { givenFlags[f.Name] = true })
+}
 
-	fmt.Printf(earthYellow+"Processing %d file(s) into .txt..."+reset+"\n", len(items))
+// exitStatus sums up a run for scripts; the most serious outcome of any
+// ticker wins.
+func exitStatus(results []TickerResult) int {
+	code := exitOK
+	for _, r := range results {
+		switch {
+		case r.cause != nil:
+			code = max(code, errExitStatus(r.cause))
+		case r.Failed > 0 || r.TimedOut > 0 || r.Canceled > 0 || r.Unreachable > 0:
+			code = max(code, exitFailed)
+		}
+	}
+	return code
+}
+
+// errExitStatus is the exit status for an error that stopped a ticker.
+func errExitStatus(err error) int {
+	var netErr net.Error
+	var amb *edgar.AmbiguousError
+	switch {
+	case errors.As(err, &netErr), errors.Is(err, edgar.ErrForbidden), errors.Is(err, edgar.ErrUnavailable):
+		return exitSetup
+	case errors.Is(err, edgar.ErrTickerNotFound), errors.Is(err, edgar.ErrCIKNotFound), errors.As(err, &amb):
+		return exitUnresolved
+	}
+	return exitFailed
+}
 
-	spinners := []string{" ", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
-	for idx, it := range items {
-		percent := float64(idx) / float64(len(items))
-		filled := int(percent * float64(barWidth))
-		bar := strings.Repeat("■", filled) + strings.Repeat(" ", barWidth-filled)
+// runTickers processes the tickers in order, or -ticker-concurrency at a
+// time. Concurrent tickers cannot share the live progress display, so their
+// detailed output is dropped and a one-line summary is printed as each one
+// finishes. The results of the tickers that ran come back in input order.
+func runTickers(ctx context.Context, tickers []string, abort context.CancelCauseFunc) []TickerResult {
+	run := func(t string) (TickerResult, edgar.Stats) {
+		ctx, stats := edgar.WithStats(ctx)
+		res := processTicker(ctx, t)
+		s := stats()
+		res.LimiterWait = s.LimiterWait.Seconds()
+		res.RateLimited = s.RateLimited
+		if client.RetryBudgetExhausted() {
+			abort(edgar.ErrRetryBudget)
+		}
+		return res, s
+	}
 
-		fmt.Printf("\r\033[K %s %s (%s) [%s%s%s] %3.0f%% ",
-			spinners[idx%len(spinners)], it.formType, it.dateStr, forestGreen, bar, reset, percent*100)
+	if rng != nil {
+		return runQueue(ctx, tickers, abort)
+	}
+	if opts.tickerConcurrency <= 1 {
+		var results []TickerResult
+		for _, t := range tickers {
+			if ctx.Err() != nil {
+				break
+			}
+			fmt.Fprintf(out, bgGray+"Ticker: "+aquaBlue+"%s%s%s\n\n", bold, t, reset)
+			res, s := run(t)
+			printRateStats(s)
+			results = append(results, res)
+		}
+		return results
+	}
 
-		err := downloadFiling(paddedCIK, it.accNum, it.docName, it.dateStr, it.formType, downloadDir)
+	ui := out
+	out = io.Discard
+	defer func() { out = ui }()
+	fmt.Fprintf(ui, "%sProcessing %d tickers, %d at a time...%s\n\n", earthYellow, len(tickers), opts.tickerConcurrency, reset)
 
-		if err != nil && !strings.Contains(err.Error(), "exists") {
-			fmt.Printf("\n%sError: %v%s\n", softRed, err, reset)
+	results := make([]TickerResult, len(tickers))
+	ran := make([]bool, len(tickers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.tickerConcurrency)
+	for i, t := range tickers {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
 		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			res, s := run(t)
+			mu.Lock()
+			defer mu.Unlock()
+			results[i], ran[i] = res, true
+			printTickerLine(ui, res, s)
+		}()
 	}
+	wg.Wait()
 
-	fmt.Printf("\r\033[K ✓ [%s] 100%% \n", strings.Repeat("■", barWidth))
-	fmt.Printf("\n%sFiles saved in: %s%s%s\n", bgGray, aquaBlue, downloadDir, reset)
+	var done []TickerResult
+	for i, r := range results {
+		if ran[i] {
+			done = append(done, r)
+		}
+	}
+	return done
 }
 
-func getCIK(ticker string) (int, error) {
-	req, _ := http.NewRequest("GET", "https://www.sec.gov/files/company_tickers.json", nil)
-	req.Header.Set("User-Agent", UserAgent)
-	resp, err := doRateLimitedRequest(req)
-	if err != nil {
-		return 0, err
+// runQueue is runTickers under -shuffle: the filings of every ticker are
+// listed first, then downloaded from one shuffled queue by the -concurrency
+// workers, so consecutive requests go to different companies.
+func runQueue(ctx context.Context, tickers []string, abort context.CancelCauseFunc) []TickerResult {
+	var results []TickerResult
+	var jobs []*tickerJob
+	for _, t := range tickers {
+		if ctx.Err() != nil {
+			break
+		}
+		fmt.Fprintf(out, bgGray+"Ticker: "+aquaBlue+"%s%s%s\n\n", bold, t, reset)
+		res, job := listTicker(ctx, t)
+		fmt.Fprintln(out)
+		results = append(results, res)
+		jobs = append(jobs, job)
+	}
+	if client.RetryBudgetExhausted() {
+		abort(edgar.ErrRetryBudget)
 	}
-	defer resp.Body.Close()
 
-	var data TickerMap
-	json.NewDecoder(resp.Body).Decode(&data)
-	for _, c := range data {
-		if strings.EqualFold(c.Ticker, ticker) {
-			return c.CIK, nil
+	// One queue entry per filing, pointing back at its ticker.
+	type entry struct {
+		job  int
+		item edgar.Filing
+	}
+	var queue []entry
+	for i, job := range jobs {
+		if job != nil {
+			for _, it := range job.items {
+				queue = append(queue, entry{i, it})
+			}
 		}
 	}
-	return 0, fmt.Errorf("ticker not found")
+	if len(queue) == 0 || ctx.Err() != nil {
+		return results
+	}
+	rng.Shuffle(len(queue), func(i, j int) { queue[i], queue[j] = queue[j], queue[i] })
+	items := make([]edgar.Filing, len(queue))
+	dirs := make([]string, len(queue))
+	for i, e := range queue {
+		items[i], dirs[i] = e.item, jobs[e.job].dir
+	}
+
+	fmt.Fprintf(out, earthYellow+"Processing %d file(s) of %d ticker(s) in random order into %s..."+reset+"\n",
+		len(items), len(tickers), edgar.FormatExt(opts.format))
+	progress := &barProgress{ctx: ctx, owner: func(r edgar.FileResult) (string, *TickerResult) {
+		i := queue[r.Index].job
+		return jobs[i].ticker, &results[i]
+	}}
+	client.DownloadAll(edgar.WithRetryHook(ctx, progress.onRetry), items, "", edgar.BatchOptions{
+		DownloadOptions: downloadOptions(),
+		Dirs:            dirs,
+		Concurrency:     opts.concurrency,
+		Exhibits:        opts.exhibits,
+		Progress:        progress,
+		FileTimeout:     opts.fileTimeout,
+	})
+	if client.RetryBudgetExhausted() {
+		abort(edgar.ErrRetryBudget)
+	}
+	for i, job := range jobs {
+		if job != nil {
+			fmt.Fprintf(out, "\n"+bgGray+"Ticker: "+aquaBlue+"%s%s%s\n", bold, job.ticker, reset)
+			finishTicker(ctx, job, &results[i])
+		}
+	}
+	return results
 }
 
-func getFilings(paddedCIK string) (Submissions, error) {
-	url := fmt.Sprintf("https://data.sec.gov/submissions/CIK%s.json", paddedCIK)
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("User-Agent", UserAgent)
-	resp, err := doRateLimitedRequest(req)
-	if err != nil {
-		return Submissions{}, err
+// printTickerLine is the summary of one ticker under -ticker-concurrency.
+func printTickerLine(w io.Writer, r TickerResult, s edgar.Stats) {
+	color := forestGreen
+	switch r.Status {
+	case statusError, statusNotFound:
+		color = softRed
+	case statusCanceled, statusNoFilings:
+		color = earthYellow
+	}
+	fmt.Fprintf(w, "%s%-8s %-10s%s %d processed, %d skipped, %d failed %s(%d requests, %s waiting, %d rate-limited)%s\n",
+		color, r.Ticker, r.Status, reset, r.Processed, r.Skipped, r.Failed,
+		bgGray, s.Requests, s.LimiterWait.Round(time.Millisecond), s.RateLimited, reset)
+	if r.Error != "" {
+		fmt.Fprintf(w, "         %s%s%s\n", softRed, r.Error, reset)
 	}
-	defer resp.Body.Close()
-	var s Submissions
-	json.NewDecoder(resp.Body).Decode(&s)
-	return s, nil
 }
 
-func downloadFiling(paddedCIK, accNum, docName, date, form, dir string) error {
-	cleanAcc := strings.ReplaceAll(accNum, "-", "")
-	unpaddedCIK := strings.TrimLeft(paddedCIK, "0")
-	url := fmt.Sprintf("https://www.sec.gov/Archives/edgar/data/%s/%s/%s", unpaddedCIK, cleanAcc, docName)
+// printRateStats tells how long requests queued on the -rps limiter and how
+// often SEC still answered 429, to help tune -rps and -concurrency.
+func printRateStats(s edgar.Stats) {
+	color := bgGray
+	if s.RateLimited > 0 {
+		color = earthYellow
+	}
+	fmt.Fprintf(out, "%s%d request(s), %s waiting on the rate limiter, %d rate-limited (429)%s\n\n",
+		color, s.Requests, s.LimiterWait.Round(time.Millisecond), s.RateLimited, reset)
+}
 
-	filename := filepath.Join(dir, fmt.Sprintf("%s_%s.txt", date, form))
+// printErrors is the whole output of -quiet: one stderr line per ticker or
+// filing that failed.
+func printErrors(results []TickerResult) {
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", r.Ticker, r.Error)
+		}
+		for _, f := range r.Filings {
+			if f.Status == "failed" || f.Status == "timed_out" {
+				fmt.Fprintf(os.Stderr, "%s %s %s (%s): %s\n", r.Ticker, f.Form, f.Date, f.Accession, f.Error)
+			}
+		}
+	}
+}
 
-	if _, err := os.Stat(filename); err == nil {
-		return nil // Skip if exists
+func processTicker(ctx context.Context, ticker string) TickerResult {
+	res, job := listTicker(ctx, ticker)
+	if job != nil {
+		downloadTicker(ctx, job, &res)
 	}
+	return res
+}
+
+// tickerJob is a ticker's selected filings, waiting to be downloaded into
+// dir.
+type tickerJob struct {
+	ticker string
+	dir    string
+	items  []edgar.Filing
+}
+
+// listTicker resolves the ticker and selects its filings. Dry runs and
+// -validate end here; otherwise the filings come back as a job for
+// downloadTicker, or the shuffled queue of runQueue. A nil job means there
+// is nothing to download.
+func listTicker(ctx context.Context, ticker string) (TickerResult, *tickerJob) {
+	res := TickerResult{Ticker: ticker, Status: statusOK}
 
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("User-Agent", UserAgent)
-	resp, err := doRateLimitedRequest(req)
+	fmt.Fprint(out, bgGray+"Looking up CIK... "+reset)
+	co, err := resolveCompany(ctx, ticker)
+	if err != nil && ctx.Err() != nil {
+		fmt.Fprintf(out, "%sCanceled%s\n", softRed, reset)
+		res.Status = statusCanceled
+		return res, nil
+	}
 	if err != nil {
-		return err
+		fmt.Fprintf(out, "%sFailed: %v%s\n", softRed, err, reset)
+		hintForbidden(err)
+		var amb *edgar.AmbiguousError
+		if errors.As(err, &amb) {
+			printCandidates(amb.Candidates)
+		}
+		res.Unresolved = true
+		res.Error = err.Error()
+		res.cause = err
+		res.Status = statusError
+		if errors.Is(err, edgar.ErrTickerNotFound) || amb != nil {
+			res.Status = statusNotFound
+		}
+		logger.Warn("ticker unresolved", "ticker", ticker, "err", err)
+		return res, nil
+	}
+	paddedCIK := edgar.PadCIK(co.CIK)
+	res.CIK = paddedCIK
+	if _, isCIK := parseCIKArg(ticker); isCIK {
+		// Keeps directory names free of the "CIK:" colon.
+		ticker = "CIK" + paddedCIK
+	}
+	logger.Info("cik resolved", "ticker", ticker, "cik", paddedCIK)
+	fmt.Fprintf(out, "%sOK: %s%s\n", forestGreen, paddedCIK, reset)
+	if co.Title != "" && !strings.EqualFold(co.Ticker, ticker) {
+		fmt.Fprintf(out, bgGray+"Matched by name: "+aquaBlue+"%s (%s)%s\n", co.Title, co.Ticker, reset)
+	}
+	if co.SeriesID != "" {
+		fmt.Fprintf(out, bgGray+"Fund series: "+aquaBlue+"%s"+bgGray+" class: "+aquaBlue+"%s%s\n", co.SeriesID, co.ClassID, reset)
 	}
-	defer resp.Body.Close()
 
-	htmlBytes, err := io.ReadAll(resp.Body)
+	downloadDir := filepath.Join(opts.outputDir, "filings_"+ticker)
+	var items []edgar.Filing
+	if opts.offline {
+		fmt.Fprint(out, bgGray+"Reading saved filings... "+reset)
+		items, err = client.LocalFilings(downloadDir, fetchOptions())
+	} else {
+		fo := fetchOptions()
+		if opts.sinceLast {
+			if last := lastSaved(downloadDir); last != "" {
+				fmt.Fprintf(out, "%sNewest saved filing: %s%s\n", bgGray, last, reset)
+				t, _ := time.Parse(time.DateOnly, last)
+				if next := t.AddDate(0, 0, 1); next.After(fo.From) {
+					fo.From = next
+				}
+			}
+		}
+		fmt.Fprint(out, bgGray+"Fetching filings... "+reset)
+		items, err = client.FetchFilings(ctx, co.CIK, fo)
+	}
+	if err != nil && ctx.Err() != nil {
+		fmt.Fprintf(out, "%sCanceled%s\n", softRed, reset)
+		res.Status = statusCanceled
+		return res, nil
+	}
 	if err != nil {
-		return fmt.Errorf("reading SEC filing body: %w", err)
+		fmt.Fprintf(out, "%sError: %v%s\n", softRed, err, reset)
+		hintForbidden(err)
+		res.Error = err.Error()
+		res.cause = err
+		res.Status = statusError
+		if errors.Is(err, edgar.ErrCIKNotFound) {
+			// A CIK that was never on EDGAR: nothing failed, it just
+			// does not resolve to a filer.
+			res.Unresolved = true
+			res.Status = statusNotFound
+		} else {
+			res.Failed++
+		}
+		return res, nil
+	}
+	fmt.Fprintf(out, "%sOK%s\n", forestGreen, reset)
+	for i := range items {
+		items[i].Company = co
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintln(out, earthYellow+"No recent filings of the requested forms found."+reset)
+		if co.SeriesID != "" && len(opts.forms) == 0 {
+			fmt.Fprintln(out, bgGray+"This is a fund; its reports need -form-group fund."+reset)
+		}
+		res.Status = statusNoFilings
+		return res, nil
+	}
+
+	if opts.interactive {
+		printFilingList(items)
+		if !isTerminal(os.Stdin) {
+			return res, nil
+		}
+		items = pickFilings(items)
+		if len(items) == 0 {
+			fmt.Fprintln(out, earthYellow+"Nothing selected."+reset)
+			return res, nil
+		}
+	}
+
+	res.Forms = make(map[string]int)
+	for _, it := range items {
+		res.Forms[it.Form]++
+		if edgar.IsAmendment(it.Form) {
+			res.Amendments++
+		}
+		if it.SupersededBy != "" {
+			res.Superseded++
+		}
 	}
+	printFormCounts(res.Forms)
+	printSuperseded(items)
+
+	if opts.dryRun {
+		for _, it := range items {
+			url := client.ArchiveURL(co.CIK, it.Accession, it.Document)
+			date := it.FilingDate
+			if opts.verbose {
+				date += acceptedClock(it)
+			}
+			fmt.Fprintf(out, "  %-8s %s  %s  %s%s%s\n", it.Form, date, it.Accession, bgGray, url, reset)
+			res.Filings = append(res.Filings, FilingResult{Form: it.Form, Date: it.FilingDate, Accession: it.Accession, Accepted: it.Accepted,
+				Path: downloadOptions().Path(downloadDir, it), Status: "dry_run", SupersededBy: it.SupersededBy})
+		}
+		fmt.Fprintf(out, "%sDry run: %d file(s) would be saved in %s%s\n", earthYellow, len(items), downloadDir, reset)
+		return res, nil
+	}
+	if opts.validate {
+		validateFilings(ctx, ticker, items, &res)
+		return res, nil
+	}
+
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		fmt.Fprintf(out, "%sError: %v%s\n", softRed, err, reset)
+		res.Failed++
+		res.Error = err.Error()
+		res.Status = statusError
+		return res, nil
+	}
+	return res, &tickerJob{ticker: ticker, dir: downloadDir, items: items}
+}
 
-	// Convert with PrettyTables OFF for better LLM tokenization
-	text, err := html2text.FromString(string(htmlBytes), html2text.Options{PrettyTables: false})
+// downloadTicker downloads the job's filings with the ticker's own progress
+// bar.
+func downloadTicker(ctx context.Context, job *tickerJob, res *TickerResult) {
+	fmt.Fprintf(out, earthYellow+"Processing %d file(s) into %s..."+reset+"\n", len(job.items), edgar.FormatExt(opts.format))
+
+	progress := &barProgress{ctx: ctx, ticker: job.ticker, res: res}
+	client.DownloadAll(edgar.WithRetryHook(ctx, progress.onRetry), job.items, job.dir, edgar.BatchOptions{
+		DownloadOptions: downloadOptions(),
+		Concurrency:     opts.concurrency,
+		Exhibits:        opts.exhibits,
+		Progress:        progress,
+		FileTimeout:     opts.fileTimeout,
+	})
+	finishTicker(ctx, job, res)
+}
+
+// finishTicker reports on a ticker once its downloads are over and sets its
+// final status.
+func finishTicker(ctx context.Context, job *tickerJob, res *TickerResult) {
+	if opts.exhibits {
+		fmt.Fprintf(out, "%sExhibit files downloaded: %s%d%s\n", bgGray, aquaBlue, res.Exhibits, reset)
+	}
+	if res.TimedOut > 0 {
+		fmt.Fprintf(out, "%s%d filing(s) timed out (-per-file-timeout %s)%s\n", softRed, res.TimedOut, opts.fileTimeout, reset)
+	}
+	if res.TooLarge > 0 {
+		fmt.Fprintf(out, "%s%d filing(s) not saved, or saved without some exhibits, for being over the size limit%s\n", earthYellow, res.TooLarge, reset)
+	}
+	fmt.Fprintf(out, "\n%sFiles saved in: %s%s%s\n", bgGray, aquaBlue, job.dir, reset)
+	switch {
+	case res.Failed > 0 || res.TimedOut > 0:
+		res.Status = statusError
+	case res.Canceled > 0 || ctx.Err() != nil:
+		res.Status = statusCanceled
+	}
+}
+
+// validateFilings probes the document URL of every item and records which
+// EDGAR serves. Reachable filings are "ok", the rest "unreachable" with the
+// status or error in their FilingResult.
+func validateFilings(ctx context.Context, ticker string, items []edgar.Filing, res *TickerResult) {
+	fmt.Fprintf(out, earthYellow+"Validating %d document URL(s)..."+reset+"\n", len(items))
+	for _, it := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		fr := FilingResult{Form: it.Form, Date: it.FilingDate, Accession: it.Accession, Accepted: it.Accepted, Status: "ok", SupersededBy: it.SupersededBy}
+		status, url, err := client.ProbeDocument(ctx, it)
+		fr.URL = url
+		switch {
+		case err != nil:
+			fr.Status, fr.Error = "unreachable", err.Error()
+		case status != http.StatusOK:
+			fr.Status, fr.Error = "unreachable", fmt.Sprintf("status %d", status)
+		}
+		label, color := "200", forestGreen
+		if fr.Status == "ok" {
+			res.Reachable++
+		} else {
+			res.Unreachable++
+			label, color = fmt.Sprint(status), softRed
+			if err != nil {
+				label = "error"
+				url = err.Error()
+			}
+		}
+		fmt.Fprintf(out, "  %s%-5s%s %-8s %s  %s%s%s\n", color, label, reset, it.Form, it.FilingDate, bgGray, url, reset)
+		res.Filings = append(res.Filings, fr)
+		emitFiling(ticker, fr)
+	}
+	fmt.Fprintf(out, "%sReachable: %d, unreachable: %d%s\n", bgGray, res.Reachable, res.Unreachable, reset)
+	if res.Unreachable > 0 {
+		res.Status = statusError
+	}
+}
+
+var forbiddenOnce sync.Once
+
+// hintForbidden explains, once per run, how to fix the 403s SEC sends when
+// the User-Agent does not identify the caller.
+func hintForbidden(err error) {
+	if !errors.Is(err, edgar.ErrForbidden) {
+		return
+	}
+	forbiddenOnce.Do(func() {
+		fmt.Fprintf(os.Stderr, "%sSEC rejected the User-Agent %q. Pass -user-agent \"Your Name you@example.com\" or set EDGAR_USER_AGENT.%s\n",
+			earthYellow, client.UserAgent, reset)
+	})
+}
+
+// filingLine is one line of -jsonl output.
+type filingLine struct {
+	Ticker string `json:"ticker"`
+	FilingResult
+}
+
+var jsonLinesMu sync.Mutex
+
+// emitFiling writes fr as a line of -jsonl output, if that is on.
+func emitFiling(ticker string, fr FilingResult) {
+	if !opts.jsonLines {
+		return
+	}
+	jsonLinesMu.Lock()
+	defer jsonLinesMu.Unlock()
+	json.NewEncoder(os.Stdout).Encode(filingLine{Ticker: ticker, FilingResult: fr})
+}
+
+func logFiling(ticker string, it edgar.Filing, result string, n int64, elapsed time.Duration, err error) {
+	attrs := []any{"ticker", ticker, "form", it.Form, "date", it.FilingDate, "accepted", it.Accepted, "accession", it.Accession,
+		"result", result, "bytes", n, "duration_ms", elapsed.Milliseconds()}
 	if err != nil {
-		return err
+		logger.Error("filing", append(attrs, "err", err)...)
+		return
 	}
+	logger.Info("filing", attrs...)
+}
 
-	// 1. Clean "Non-Breaking" Spaces (SEC filings are full of these)
-	text = strings.ReplaceAll(text, "\u00a0", " ")
+// lastSaved returns the filing date of the newest filing in dir that -forms
+// selects, or "" when there is none yet.
+func lastSaved(dir string) string {
+	saved, err := client.LocalFilings(dir, edgar.FetchOptions{Forms: opts.forms.list(), IncludeAmendments: opts.amendments})
+	if err != nil || len(saved) == 0 {
+		return ""
+	}
+	return saved[0].FilingDate
+}
 
-	// 2. XBRL "Soup" Stripper
-	// Removes lines starting with technical schema links (http, xbrli, etc.)
-	reSoup := regexp.MustCompile(`(?m)^(http|https|xmlns|xbrli):.*$`)
-	text = reSoup.ReplaceAllString(text, "")
+// printSuperseded warns about the items a later amendment replaces, whose
+// figures may be out of date.
+func printSuperseded(items []edgar.Filing) {
+	for _, it := range items {
+		if it.SupersededBy != "" {
+			fmt.Fprintf(out, "%s⚠ %s %s (period %s) is superseded by amendment %s%s\n",
+				earthYellow, it.Form, it.FilingDate, it.ReportDate, it.SupersededBy, reset)
+		}
+	}
+}
 
-	// 3. Fix "Drifting" Symbols (Keeps currencies and negatives connected)
-	// Joins $ and ( to the numbers they belong to
-	reDrift := regexp.MustCompile(`([$\(\-])\s+`)
-	text = reDrift.ReplaceAllString(text, "$1")
-	reParensClose := regexp.MustCompile(`\s+\)`)
-	text = reParensClose.ReplaceAllString(text, ")")
+// printFormCounts lists how many filings of each form were selected, with
+// amendments highlighted so restatements stand out.
+func printFormCounts(forms map[string]int) {
+	names := make([]string, 0, len(forms))
+	for f := range forms {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, f := range names {
+		color := aquaBlue
+		if edgar.IsAmendment(f) {
+			color = earthYellow
+		}
+		parts = append(parts, fmt.Sprintf("%s%s%s %d", color, f, reset, forms[f]))
+	}
+	fmt.Fprintf(out, "%sForms:%s %s\n", bgGray, reset, strings.Join(parts, ", "))
+}
 
-	// 4. Kill lines that contain ONLY whitespace (spaces/tabs)
-	// This allows the next step to catch "empty" lines that aren't actually empty.
-	reOnlyWhitespace := regexp.MustCompile(`(?m)^[ \t]+$`)
-	text = reOnlyWhitespace.ReplaceAllString(text, "")
+// disableColors blanks the palette so output stays plain text.
+func disableColors() {
+	for _, c := range []*string{&reset, &bold, &dim, &forestGreen, &earthYellow, &aquaBlue, &softRed, &bgGray} {
+		*c = ""
+	}
+}
 
-	// 5. Page Number Stripping (removes standalone digits or "Page X")
-	rePage := regexp.MustCompile(`(?m)^(\s*\d+\s*|\s*[Pp]age\s+\d+\s*)$`)
-	text = rePage.ReplaceAllString(text, "")
+// filingDates shows the filing date and, when EDGAR has one, the fiscal
+// period the filing reports on.
+func filingDates(it edgar.Filing) string {
+	if it.ReportDate == "" || it.ReportDate == it.FilingDate {
+		return it.FilingDate
+	}
+	return it.FilingDate + ", period " + it.ReportDate
+}
+
+// acceptedClock is the time of day EDGAR accepted it, as " 16:05:12", to
+// tell same-day filings apart; "" when EDGAR gave none.
+func acceptedClock(it edgar.Filing) string {
+	if _, clock, ok := strings.Cut(it.Accepted, "T"); ok && len(clock) >= 8 {
+		return " " + clock[:8]
+	}
+	return ""
+}
 
-	// 6. Collapse multiple newlines (3+ becomes 2)
-	// NotebookLM prefers double-newlines for distinct context blocks.
-	reMultiLine := regexp.MustCompile(`\n{3,}`)
-	text = reMultiLine.ReplaceAllString(text, "\n\n")
+func printCandidates(cs []edgar.Company) {
+	for i, c := range cs {
+		fmt.Fprintf(out, "%s%3d%s  %-6s %s  %s%s%s\n", aquaBlue, i+1, reset, c.Ticker, edgar.PadCIK(c.CIK), bgGray, c.Title, reset)
+	}
+}
 
-	// 7. Trim leading/trailing whitespace
-	text = strings.TrimSpace(text)
+// pickCompany lets the user choose among candidates, introduced by title;
+// an empty answer gives up with the original error.
+func pickCompany(title string, candidates []edgar.Company, orig error) (edgar.Company, error) {
+	fmt.Fprintf(out, "%s%s%s\n", earthYellow, title, reset)
+	printCandidates(candidates)
+	for {
+		fmt.Fprintf(out, "%s%sSelect a company (1-%d, empty to skip): %s", aquaBlue, bold, len(candidates), reset)
+		line, err := stdin.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return edgar.Company{}, orig
+		}
+		if n, perr := strconv.Atoi(line); perr == nil && n >= 1 && n <= len(candidates) {
+			return candidates[n-1], nil
+		}
+		fmt.Fprintf(out, "%s%q is not a number from 1 to %d%s\n", softRed, line, len(candidates), reset)
+		if err != nil {
+			return edgar.Company{}, orig
+		}
+	}
+}
 
-	// 8. Build the final output with Metadata at the TOP
-	// Using a distinct header helps the AI cite its sources chronologically.
-	header := fmt.Sprintf("--- METADATA ---\nCOMPANY: %s\nCIK: %s\nFORM: %s\nDATE: %s\n----------------\n\n",
-		strings.Replace(dir, "filings_", "", 1), paddedCIK, form, date)
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
 
-	finalContent := header + text
+func printFilingList(items []edgar.Filing) {
+	for i, it := range items {
+		fmt.Fprintf(out, "%s%3d%s  %-8s %-30s %s%s  %s%s\n", aquaBlue, i+1, reset, it.Form, filingDates(it), bgGray, it.Accession, it.Description, reset)
+	}
+}
 
-	return os.WriteFile(filename, []byte(finalContent), 0644)
+// pickFilings prompts until the user enters a valid selection.
+func pickFilings(items []edgar.Filing) []edgar.Filing {
+	for {
+		fmt.Fprint(out, aquaBlue+bold+"Select filings (e.g. 1-3,5; empty for none): "+reset)
+		line, err := stdin.ReadString('\n')
+		if strings.TrimSpace(line) == "" {
+			return nil
+		}
+		idx, perr := parseSelection(line, len(items))
+		if perr == nil {
+			picked := make([]edgar.Filing, 0, len(idx))
+			for _, i := range idx {
+				picked = append(picked, items[i])
+			}
+			return picked
+		}
+		fmt.Fprintf(out, "%s%v%s\n", softRed, perr, reset)
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// parseSelection turns "1-3,5" into zero-based, de-duplicated indices in
+// ascending order, rejecting anything outside 1..n.
+func parseSelection(s string, n int) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+				return nil, fmt.Errorf("invalid selection %q", part)
+			}
+		}
+		if from < 1 || to > n || from > to {
+			return nil, fmt.Errorf("selection %q out of range 1-%d", part, n)
+		}
+		for i := from; i <= to; i++ {
+			seen[i-1] = true
+		}
+	}
+	idx := make([]int, 0, len(seen))
+	for i := range seen {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+	return idx, nil
+}
+
+// parseCIKArg recognises CIKs given instead of a ticker, either bare digits
+// ("320193") or prefixed ("CIK:0000320193").
+func parseCIKArg(arg string) (int, bool) {
+	s := strings.TrimSpace(arg)
+	if len(s) > 4 && strings.EqualFold(s[:4], "CIK:") {
+		s = s[4:]
+	}
+	if s == "" || len(s) > 10 || strings.Trim(s, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// resolveCompany skips the ticker lookup when the argument is already a CIK,
+// and on a terminal asks which company an ambiguous name, or a mistyped
+// ticker with close matches, meant.
+func resolveCompany(ctx context.Context, arg string) (edgar.Company, error) {
+	if cik, ok := parseCIKArg(arg); ok {
+		return edgar.Company{CIK: cik}, nil
+	}
+	co, err := client.LookupTicker(ctx, arg)
+	// Ask only when someone can see the question and answer it.
+	if out != os.Stdout || !isTerminal(os.Stdin) {
+		return co, err
+	}
+	var amb *edgar.AmbiguousError
+	var nf *edgar.NotFoundError
+	switch {
+	case errors.As(err, &amb):
+		fmt.Fprintln(out)
+		return pickCompany(fmt.Sprintf("%q matches %d companies:", amb.Query, len(amb.Candidates)), amb.Candidates, err)
+	case errors.As(err, &nf) && len(nf.Suggestions) > 0:
+		fmt.Fprintln(out)
+		return pickCompany(fmt.Sprintf("%q not found. Did you mean:", nf.Query), nf.Suggestions, err)
+	}
+	return co, err
 }
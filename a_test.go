@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"edgarv2/edgar"
+	"golang.org/x/time/rate"
+)
+
+// useServer points the global client at a server running h and resets
+// opts, out and the client when the test ends.
+func useServer(t *testing.T, h http.Handler) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	savedOpts, savedOut, savedClient := opts, out, client
+	t.Cleanup(func() {
+		srv.Close()
+		opts, out, client = savedOpts, savedOut, savedClient
+	})
+	client = edgar.NewClient()
+	client.Limiter = rate.NewLimiter(rate.Inf, 1)
+	client.CacheDir = ""
+	client.SECBaseURL, client.DataBaseURL, client.SearchBaseURL = srv.URL, srv.URL, srv.URL
+	opts = options{forms: formSet{}, outputDir: t.TempDir()}
+}
+
+// TestInteractiveIgnoresLimit checks that -interactive lists every match,
+// not the first -limit: the limit would hide filings the user could pick.
+// Without a terminal the list is printed and nothing is downloaded.
+func TestInteractiveIgnoresLimit(t *testing.T) {
+	useServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/submissions/CIK0000320193.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"cik":"320193","filings":{"recent":{` +
+			`"form":["10-K","10-K","10-K"],` +
+			`"accessionNumber":["0000320193-24-000123","0000320193-23-000106","0000320193-22-000108"],` +
+			`"primaryDocument":["a.htm","b.htm","c.htm"],` +
+			`"filingDate":["2024-11-01","2023-11-03","2022-10-28"],` +
+			`"reportDate":["2024-09-28","2023-09-30","2022-09-24"]}}}`))
+	}))
+	opts.forms.Set("10-K")
+	opts.limit = 1
+	var buf bytes.Buffer
+	out = &buf
+	// A pipe is no terminal, whatever the test runs under.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedStdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = savedStdin; r.Close(); w.Close() })
+
+	if fo := fetchOptions(); fo.Limit != 1 {
+		t.Errorf("fetchOptions().Limit = %d, want -limit 1", fo.Limit)
+	}
+	opts.interactive = true
+	if fo := fetchOptions(); fo.Limit != 0 {
+		t.Errorf("fetchOptions().Limit = %d under -interactive, want 0", fo.Limit)
+	}
+
+	res, job := listTicker(context.Background(), "CIK:320193")
+	if job != nil {
+		t.Errorf("listing without a terminal queued %d download(s)", len(job.items))
+	}
+	if res.Status != statusOK {
+		t.Errorf("status %q: %s", res.Status, res.Error)
+	}
+	for _, acc := range []string{"0000320193-24-000123", "0000320193-23-000106", "0000320193-22-000108"} {
+		if !strings.Contains(buf.String(), acc) {
+			t.Errorf("%s missing from the list:\n%s", acc, buf.String())
+		}
+	}
+}
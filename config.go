@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Config file
+// ──────────────────────────────────────────────────────────────────────────────
+//
+// A JSON object whose keys are flag names supplies defaults for the flags not
+// given on the command line, e.g.
+//
+//	{"user-agent": "Jane Doe jane@example.com", "rps": 5, "forms": ["10-K", "8-K"]}
+//
+// Repeatable flags (-forms, -resolve) take a list.
+
+// givenFlags are the flags set on the command line, globally or after a
+// subcommand; the config file does not override them.
+var givenFlags = map[string]bool{}
+
+// sharedFlags fill the same option, so giving either on the command line
+// also keeps the config file's value for the other out: -form-group adds
+// to the -forms list.
+var sharedFlags = map[string][]string{
+	"forms":      {"forms", "form-group"},
+	"form-group": {"forms", "form-group"},
+}
+
+// overridden reports whether the command line set the flag, or one that
+// fills the same option.
+func overridden(name string) bool {
+	if givenFlags[name] {
+		return true
+	}
+	for _, other := range sharedFlags[name] {
+		if givenFlags[other] {
+			return true
+		}
+	}
+	return false
+}
+
+// configPath is -config, else config.json in the user's edgarv2 config
+// directory.
+func configPath() (path string, explicit bool) {
+	if opts.config != "" {
+		return opts.config, true
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(dir, "edgarv2", "config.json"), false
+}
+
+// loadConfig applies the config file to the global flags. A missing default
+// file is not an error; a missing -config file is.
+func loadConfig() error {
+	path, explicit := configPath()
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := strings.TrimLeft(key, "-")
+		if flag.Lookup(name) == nil || name == "config" {
+			return fmt.Errorf("%s: %q is not a flag", path, key)
+		}
+		if overridden(name) {
+			continue
+		}
+		values, err := configValues(raw[key])
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+		for _, v := range values {
+			if err := flag.Set(name, v); err != nil {
+				return fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// configValues turns a JSON value into the flag values it stands for: a
+// string, number or bool is one value, a list one value per element.
+func configValues(raw json.RawMessage) ([]string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, err
+		}
+		var values []string
+		for _, item := range list {
+			v, err := configValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+	v, err := configValue(raw)
+	if err != nil {
+		return nil, err
+	}
+	return []string{v}, nil
+}
+
+func configValue(raw json.RawMessage) (string, error) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, nil
+	}
+	var n json.Number
+	if json.Unmarshal(raw, &n) == nil {
+		return n.String(), nil
+	}
+	var b bool
+	if json.Unmarshal(raw, &b) == nil {
+		return fmt.Sprint(b), nil
+	}
+	return "", fmt.Errorf("want a string, number, bool or list, got %s", raw)
+}
@@ -0,0 +1,149 @@
+package edgar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Batch downloads
+// ──────────────────────────────────────────────────────────────────────────────
+
+// Progress follows a DownloadAll run, so embedders can draw their own
+// progress display, or none. DownloadAll calls it from a single goroutine.
+type Progress interface {
+	OnStart(total int)
+	OnFile(r FileResult, done, total int)
+	OnDone(done, total int)
+}
+
+// FileResult is the outcome of one filing in DownloadAll. Err is
+// ErrFileExists for filings that were already on disk.
+type FileResult struct {
+	Filing Filing
+	// Index is the filing's position in the slice given to DownloadAll.
+	Index    int
+	Path     string
+	Bytes    int64
+	Exhibits int
+	Elapsed  time.Duration
+	Err      error
+}
+
+// BatchOptions configures DownloadAll.
+type BatchOptions struct {
+	DownloadOptions
+	// Concurrency is the number of parallel downloads; less than 1 means 1.
+	// All of them share the client's rate limiter.
+	Concurrency int
+	// Exhibits also runs DownloadExhibits for every filing.
+	Exhibits bool
+	// Dirs, if set, holds a directory per filing, Dirs[i] for filings[i],
+	// in place of DownloadAll's dir, so one pool can work through the
+	// filings of several companies.
+	Dirs []string
+	// Progress, if set, is told about the run as it goes.
+	Progress Progress
+	// FileTimeout bounds each attempt at one filing, exhibits included. A
+	// filing that runs out of time is tried again, resuming what it saved,
+	// up to MaxRetries times and then fails with ErrFileTimeout while the
+	// rest of the batch goes on. 0 means no limit beyond ctx.
+	FileTimeout time.Duration
+}
+
+// ErrFileTimeout is the error of a filing that used up its FileTimeout on
+// every attempt.
+var ErrFileTimeout = errors.New("filing timed out")
+
+type noProgress struct{}
+
+func (noProgress) OnStart(int)                 {}
+func (noProgress) OnFile(FileResult, int, int) {}
+func (noProgress) OnDone(int, int)             {}
+
+// DownloadAll downloads filings into dir with a pool of workers and returns
+// one result per finished filing, in completion order. Once ctx is done no
+// new filing is started and the running ones are canceled.
+func (c *Client) DownloadAll(ctx context.Context, filings []Filing, dir string, opts BatchOptions) []FileResult {
+	progress := opts.Progress
+	if progress == nil {
+		progress = noProgress{}
+	}
+	jobs := make(chan int)
+	done := make(chan FileResult)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(opts.Concurrency, 1), len(filings)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f, dir := filings[i], dir
+				if opts.Dirs != nil {
+					dir = opts.Dirs[i]
+				}
+				start := time.Now()
+				r := FileResult{Filing: f, Index: i, Path: opts.Path(dir, f)}
+				for attempt := 1; ; attempt++ {
+					if !c.downloadOne(ctx, f, dir, opts, &r) || ctx.Err() != nil {
+						break
+					}
+					if attempt == MaxRetries {
+						r.Err = fmt.Errorf("%w after %d attempts of %s", ErrFileTimeout, MaxRetries, opts.FileTimeout)
+						break
+					}
+					c.Logger.Warn("filing timed out, retrying", "accession", f.Accession, "timeout", opts.FileTimeout, "attempt", attempt)
+				}
+				r.Elapsed = time.Since(start)
+				done <- r
+			}
+		}()
+	}
+	go func() {
+		// After cancellation nothing new is started; the workers drain out.
+	send:
+		for i := range filings {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				break send
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(done)
+	}()
+
+	progress.OnStart(len(filings))
+	var results []FileResult
+	for r := range done {
+		results = append(results, r)
+		progress.OnFile(r, len(results), len(filings))
+	}
+	progress.OnDone(len(results), len(filings))
+	return results
+}
+
+// downloadOne makes one attempt at f and its exhibits, within FileTimeout,
+// and reports whether it failed for running out of that time.
+func (c *Client) downloadOne(ctx context.Context, f Filing, dir string, opts BatchOptions, r *FileResult) (timedOut bool) {
+	if opts.FileTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.FileTimeout)
+		defer cancel()
+	}
+	r.Exhibits = 0
+	r.Bytes, r.Err = c.Download(ctx, f, dir, opts.DownloadOptions)
+	if opts.Exhibits && (r.Err == nil || errors.Is(r.Err, ErrFileExists)) {
+		n, exBytes, err := c.DownloadExhibits(ctx, f, dir, opts.MaxDocBytes)
+		r.Exhibits = n
+		r.Bytes += exBytes
+		if err != nil {
+			r.Err = err
+		}
+	}
+	return r.Err != nil && !errors.Is(r.Err, ErrFileExists) && errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
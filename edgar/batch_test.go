@@ -0,0 +1,50 @@
+package edgar
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDownloadAllDirs runs one pool over the filings of two companies, each
+// saved into its own directory and reported under its own index.
+func TestDownloadAllDirs(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body><p>" + path.Base(r.URL.Path) + "</p></body></html>"))
+	}))
+	mem := newMemFS()
+	c.FS = mem
+	root := t.TempDir()
+	filings := []Filing{
+		{Company: Company{CIK: 320193, Ticker: "AAPL"}, Form: "10-K", Accession: "0000320193-24-000123", Document: "aapl.htm", FilingDate: "2024-11-01"},
+		{Company: Company{CIK: 789019, Ticker: "MSFT"}, Form: "10-K", Accession: "0000950170-24-087843", Document: "msft.htm", FilingDate: "2024-07-30"},
+		{Company: Company{CIK: 320193, Ticker: "AAPL"}, Form: "10-Q", Accession: "0000320193-24-000081", Document: "aapl-q.htm", FilingDate: "2024-08-02"},
+	}
+	dirs := []string{filepath.Join(root, "filings_AAPL"), filepath.Join(root, "filings_MSFT"), filepath.Join(root, "filings_AAPL")}
+	for _, d := range dirs {
+		mem.MkdirAll(d, 0755)
+	}
+
+	results := c.DownloadAll(context.Background(), filings, "", BatchOptions{Dirs: dirs, Concurrency: 2})
+	if len(results) != len(filings) {
+		t.Fatalf("%d results, want %d", len(results), len(filings))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		if r.Filing.Accession != filings[r.Index].Accession {
+			t.Errorf("result for %s has index %d", r.Filing.Accession, r.Index)
+		}
+		if filepath.Dir(r.Path) != dirs[r.Index] {
+			t.Errorf("%s saved as %s, want it in %s", r.Filing.Document, r.Path, dirs[r.Index])
+		}
+		text, err := mem.ReadFile(r.Path)
+		if err != nil || !strings.Contains(string(text), r.Filing.Document) {
+			t.Errorf("%s: %q, %v", r.Path, text, err)
+		}
+	}
+}
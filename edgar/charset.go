@@ -0,0 +1,65 @@
+package edgar
+
+import (
+	"bytes"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Character sets
+// ──────────────────────────────────────────────────────────────────────────────
+//
+// Filings from the 1990s and 2000s are often Latin-1 or Windows-1252 rather
+// than UTF-8. Only those are decoded here: together with UTF-8 they cover
+// what EDGAR actually serves, without pulling in golang.org/x/text.
+
+var reMetaCharset = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?([\w-]+)`)
+
+// cp1252 holds the characters Windows-1252 puts at 0x80-0x9F, where Latin-1
+// has control codes. Unassigned positions keep their Latin-1 meaning.
+var cp1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// toUTF8 returns doc as UTF-8 without a byte order mark. The charset comes
+// from the Content-Type header, else from a <meta> tag; a document that
+// claims nothing, or claims UTF-8 but is not valid UTF-8, is read as
+// Windows-1252.
+func toUTF8(doc []byte, contentType string) []byte {
+	doc = bytes.TrimPrefix(doc, []byte("\xef\xbb\xbf"))
+
+	charset := ""
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		charset = params["charset"]
+	}
+	if charset == "" {
+		if m := reMetaCharset.FindSubmatch(doc[:min(len(doc), 4096)]); m != nil {
+			charset = string(m[1])
+		}
+	}
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "latin-1", "windows-1252", "cp1252":
+	default:
+		if utf8.Valid(doc) {
+			return doc
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(doc) + len(doc)/8)
+	for _, c := range doc {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c < 0xa0:
+			b.WriteRune(cp1252[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,49 @@
+package edgar
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         []byte
+		contentType string
+		want        string
+	}{
+		{"0x92 smart quote, nothing declared", []byte("the Company\x92s shares"), "", "the Company’s shares"},
+		{"cp1252 quotes, dash and euro", []byte("\x93net\x94 \x96 \x80100"), "", "“net” – €100"},
+		{"Latin-1 letters", []byte("Soci\xe9t\xe9 G\xe9n\xe9rale"), "", "Société Générale"},
+		{"meta charset", []byte(`<html><head><meta charset="windows-1252"></head><body>caf` + "\xe9</body></html>"), "",
+			`<html><head><meta charset="windows-1252"></head><body>café</body></html>`},
+		{"meta http-equiv charset", []byte(`<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1">` + "\xa9 2004"), "",
+			`<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1">© 2004`},
+		{"Content-Type charset", []byte("caf\xe9"), "text/html; charset=ISO-8859-1", "café"},
+		{"Content-Type wins over meta", []byte(`<meta charset="utf-8">` + "\x92"), "text/html; charset=windows-1252", `<meta charset="utf-8">’`},
+		{"UTF-8 claimed but invalid", []byte("it\x92s"), "text/html; charset=utf-8", "it’s"},
+		{"UTF-8 BOM dropped", []byte("\xef\xbb\xbfplain"), "", "plain"},
+	}
+	for _, tt := range tests {
+		if got := string(toUTF8(tt.doc, tt.contentType)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestToUTF8PassThrough checks that valid UTF-8 comes back byte for byte,
+// including the bytes 0x80-0x9F inside multi-byte sequences.
+func TestToUTF8PassThrough(t *testing.T) {
+	for _, doc := range []string{
+		"plain ASCII",
+		"the Company’s “shares” – €100 © Société",
+		"日本語のテキスト",
+		"<html><body>\u0092 is a C1 control as UTF-8</body></html>",
+	} {
+		for _, contentType := range []string{"", "text/html", "text/html; charset=utf-8"} {
+			if got := toUTF8([]byte(doc), contentType); !bytes.Equal(got, []byte(doc)) {
+				t.Errorf("%q with %q changed to %q", doc, contentType, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,425 @@
+// Package edgar downloads SEC EDGAR filings from the official SEC endpoints.
+//
+// It is the engine behind the edgarv2 command but prints nothing itself:
+// every function returns values and errors, so it can be embedded in other
+// programs. All requests made through one Client share its rate limiter.
+package edgar
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultUserAgent is a placeholder. SEC asks for a real name and
+	// contact address, so callers should set Client.UserAgent.
+	DefaultUserAgent  = "Company SysAdmin contact@yahoo.com"
+	MaxRetries        = 5
+	DefaultRetryDelay = 5 * time.Second
+	MaxRetryDelay     = time.Minute
+
+	// DefaultHTTPTimeout bounds each attempt of a request, reading the body
+	// included. Timing out before the response arrives is retried like any
+	// network error; timing out while reading the body fails the request.
+	DefaultHTTPTimeout = 45 * time.Second
+)
+
+var (
+	ErrFileExists      = errors.New("file already exists")
+	ErrTickerNotFound  = errors.New("ticker not found")
+	ErrCIKNotFound     = errors.New("no EDGAR submissions for CIK")
+	ErrTooLarge        = errors.New("document exceeds the size limit")
+	ErrRetryLater      = errors.New("server asked to retry later")
+	ErrOffline         = errors.New("not available offline")
+	ErrSectionNotFound = errors.New("section not found")
+	ErrRetryBudget     = errors.New("retry budget exhausted")
+	ErrNotDocument     = errors.New("SEC sent an index or error page instead of the document")
+	ErrForbidden       = errors.New("SEC refused the request (403 Forbidden); it requires a User-Agent naming you and your email")
+	ErrUnavailable     = errors.New("EDGAR is unavailable (503 Service Unavailable), probably down for maintenance; try again later")
+)
+
+// The EDGAR hosts a Client uses unless its base URL fields say otherwise.
+const (
+	DefaultSECBaseURL    = "https://www.sec.gov"
+	DefaultDataBaseURL   = "https://data.sec.gov"
+	DefaultSearchBaseURL = "https://efts.sec.gov"
+)
+
+// Client talks to EDGAR. Create one with NewClient and adjust its fields
+// before first use.
+type Client struct {
+	HTTP      *http.Client
+	Limiter   *rate.Limiter
+	UserAgent string
+	Logger    *slog.Logger
+
+	// Offline forbids network access: cached ticker lists are used whatever
+	// their age and Download reads documents saved by earlier runs.
+	Offline bool
+
+	// MaxRetryAfter is the longest Retry-After the client will sleep for;
+	// longer requests fail with ErrRetryLater. 0 means no cap.
+	MaxRetryAfter time.Duration
+
+	// MaxFileBytes caps every file saved, exhibits and complete submissions
+	// included, on top of DownloadOptions.MaxDocBytes. A larger file fails
+	// with ErrTooLarge, from its Content-Length when the server sends one,
+	// and nothing of it is kept. 0 means no cap.
+	MaxFileBytes int64
+
+	// MaxTotalRetries caps the retries of all requests together, on top of
+	// MaxRetries per request. Once it is spent every request fails with
+	// ErrRetryBudget. 0 means no cap.
+	MaxTotalRetries int
+	retries         atomic.Int64
+
+	// jitter replaces the random draw of backoff, so tests can pin it.
+	jitter func(window time.Duration) time.Duration
+
+	statsMu sync.Mutex
+	stats   Stats
+
+	// FS is where filings, manifests and the ticker cache are written and
+	// read back; nil means the operating system's (OSFS).
+	FS FS
+
+	// CacheDir holds the downloaded ticker lists; empty disables caching.
+	CacheDir       string
+	TickersTTL     time.Duration
+	RefreshTickers bool
+
+	// SECBaseURL (filing archives and ticker lists), DataBaseURL
+	// (submissions and XBRL APIs) and SearchBaseURL (full-text search)
+	// replace the SEC hosts, e.g. with an httptest server or an internal
+	// mirror of EDGAR that keeps the same paths. Empty means the SEC host;
+	// a trailing slash is ignored.
+	SECBaseURL    string
+	DataBaseURL   string
+	SearchBaseURL string
+}
+
+func baseURL(base, def string) string {
+	if base == "" {
+		return def
+	}
+	return strings.TrimRight(base, "/")
+}
+
+func (c *Client) secURL() string    { return baseURL(c.SECBaseURL, DefaultSECBaseURL) }
+func (c *Client) dataURL() string   { return baseURL(c.DataBaseURL, DefaultDataBaseURL) }
+func (c *Client) searchURL() string { return baseURL(c.SearchBaseURL, DefaultSearchBaseURL) }
+
+// NewClient returns a Client with SEC-compliant defaults: 8 requests per
+// second and a 24h ticker cache in the user cache directory.
+func NewClient() *Client {
+	c := &Client{
+		HTTP:       &http.Client{Timeout: DefaultHTTPTimeout, Transport: NewTransport(nil)},
+		Limiter:    rate.NewLimiter(rate.Limit(8), 8),
+		UserAgent:  DefaultUserAgent,
+		Logger:     slog.New(slog.DiscardHandler),
+		TickersTTL: 24 * time.Hour,
+
+		MaxRetryAfter: 5 * time.Minute,
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		c.CacheDir = filepath.Join(dir, "edgarv2")
+	}
+	return c
+}
+
+// DefaultClient is used by the package-level FetchFilings and Download.
+var DefaultClient = NewClient()
+
+// NewTransport dials pinned hosts (lower-case host → IP) at their override
+// address. TLS verification and the Host header still use the original name.
+// Requests go through the proxy named by HTTP_PROXY, HTTPS_PROXY and NO_PROXY;
+// set Proxy on the result to use another one.
+func NewTransport(resolve map[string]string) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			if ip, ok := resolve[strings.ToLower(host)]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return t
+}
+
+// Stats counts how a client's requests fared against the rate limit, to
+// help tune the request rate and concurrency.
+type Stats struct {
+	Requests    int           // requests sent, not counting retries
+	LimiterWait time.Duration // total time spent waiting on Limiter
+	RateLimited int           // 429 Too Many Requests responses
+}
+
+// Stats returns the counts of every request the client has made so far.
+func (c *Client) Stats() Stats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	return c.stats
+}
+
+type statsKey struct{}
+
+// statsRecorder is the tally installed by WithStats.
+type statsRecorder struct {
+	mu sync.Mutex
+	s  Stats
+}
+
+// WithStats returns a context whose requests are also counted apart from
+// the client's totals, e.g. per ticker while several run at once, and a
+// function that reads those counts.
+func WithStats(ctx context.Context) (context.Context, func() Stats) {
+	r := &statsRecorder{}
+	return context.WithValue(ctx, statsKey{}, r), func() Stats {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.s
+	}
+}
+
+func (c *Client) count(ctx context.Context, f func(*Stats)) {
+	c.statsMu.Lock()
+	f(&c.stats)
+	c.statsMu.Unlock()
+	if r, ok := ctx.Value(statsKey{}).(*statsRecorder); ok {
+		r.mu.Lock()
+		f(&r.s)
+		r.mu.Unlock()
+	}
+}
+
+// RetryHook is told about each back-off so callers can surface it, e.g. on
+// a progress line. status is 0 when the attempt failed at the network level.
+type RetryHook func(delay time.Duration, status int)
+
+type retryHookKey struct{}
+
+// WithRetryHook returns a context whose requests report back-offs to h.
+func WithRetryHook(ctx context.Context, h RetryHook) context.Context {
+	return context.WithValue(ctx, retryHookKey{}, h)
+}
+
+// parseRetryAfter reads either form of Retry-After: delay-seconds or an
+// HTTP date, taken relative to now. It returns 0 when there is nothing usable.
+func parseRetryAfter(val string, now time.Time) time.Duration {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(val); err == nil {
+		if secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		return 0
+	}
+	if t, err := http.ParseTime(val); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// backoff picks the pause after a failed attempt (1-based) when the server
+// gave no Retry-After: a random delay below a window that starts at
+// DefaultRetryDelay and doubles per attempt up to MaxRetryDelay. The
+// randomness ("full jitter") keeps parallel clients from retrying in step.
+// randN draws a delay in [0, window); nil means math/rand.
+func backoff(attempt int, randN func(window time.Duration) time.Duration) time.Duration {
+	window := MaxRetryDelay
+	if attempt < 10 {
+		window = min(DefaultRetryDelay<<(attempt-1), MaxRetryDelay)
+	}
+	if randN == nil {
+		randN = rand.N[time.Duration]
+	}
+	return randN(window)
+}
+
+// doRateLimitedRequest retries network errors, 429 and 5xx responses with a
+// growing, jittered back-off (or the server's Retry-After). Other statuses,
+// including 403 and 404, are returned to the caller at once; they would not
+// change on a retry. A 503, which SEC sends during maintenance, that outlasts
+// the retries fails with ErrUnavailable.
+func (c *Client) doRateLimitedRequest(req *http.Request) (*http.Response, error) {
+	if c.RetryBudgetExhausted() {
+		return nil, ErrRetryBudget
+	}
+	waitStart := time.Now()
+	err := c.Limiter.Wait(req.Context())
+	waited := time.Since(waitStart)
+	c.count(req.Context(), func(s *Stats) {
+		s.Requests++
+		s.LimiterWait += waited
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+	onRetry, _ := req.Context().Value(retryHookKey{}).(RetryHook)
+	var lastErr error
+	for attempt := 1; attempt <= MaxRetries; attempt++ {
+		var delay time.Duration
+		status := 0
+		resp, err := c.HTTP.Do(req)
+		switch {
+		case err != nil:
+			// Only the caller's context ends the loop; an HTTP.Timeout
+			// expiring on one attempt is retried.
+			if req.Context().Err() != nil {
+				return nil, err
+			}
+			c.Logger.Error("request failed", "url", req.URL.String(), "attempt", attempt, "err", err)
+			lastErr = err
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			status = resp.StatusCode
+			delay = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			resp.Body.Close()
+			lastErr = fmt.Errorf("status %d", status)
+			switch status {
+			case http.StatusTooManyRequests:
+				c.Logger.Warn("rate limited (429)", "url", req.URL.String(), "retry_after", delay, "attempt", attempt)
+				c.count(req.Context(), func(s *Stats) { s.RateLimited++ })
+			case http.StatusServiceUnavailable:
+				c.Logger.Warn("service unavailable (503), maybe maintenance", "url", req.URL.String(), "retry_after", delay, "attempt", attempt)
+				lastErr = ErrUnavailable
+			default:
+				c.Logger.Warn("retryable status", "url", req.URL.String(), "status", status, "attempt", attempt)
+			}
+			if c.MaxRetryAfter > 0 && delay > c.MaxRetryAfter {
+				if status == http.StatusServiceUnavailable {
+					return nil, fmt.Errorf("%w: Retry-After is %s, more than the %s cap: %w", ErrRetryLater, delay.Round(time.Second), c.MaxRetryAfter, ErrUnavailable)
+				}
+				return nil, fmt.Errorf("%w: Retry-After is %s, more than the %s cap", ErrRetryLater, delay.Round(time.Second), c.MaxRetryAfter)
+			}
+		default:
+			c.Logger.Info("request", "url", req.URL.String(), "status", resp.StatusCode, "attempt", attempt)
+			return resp, nil
+		}
+		if attempt == MaxRetries {
+			break
+		}
+		if c.MaxTotalRetries > 0 && c.retries.Add(1) > int64(c.MaxTotalRetries) {
+			return nil, fmt.Errorf("%w (%d retries), last error: %w", ErrRetryBudget, c.MaxTotalRetries, lastErr)
+		}
+
+		if delay <= 0 {
+			delay = backoff(attempt, c.jitter)
+		}
+		c.Logger.Warn("retrying", "url", req.URL.String(), "delay", delay)
+		if onRetry != nil {
+			onRetry(delay, status)
+		}
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", MaxRetries, lastErr)
+}
+
+// RetryBudgetExhausted reports whether MaxTotalRetries has been used up.
+func (c *Client) RetryBudgetExhausted() bool {
+	return c.MaxTotalRetries > 0 && c.retries.Load() > int64(c.MaxTotalRetries)
+}
+
+// get issues a rate-limited GET with the client's User-Agent.
+func (c *Client) get(ctx context.Context, url string) (*http.Response, error) {
+	return c.getFrom(ctx, url, 0)
+}
+
+// getFrom is get asking only for the bytes from offset on (a Range request)
+// when offset is positive. Servers may still answer with the whole body.
+func (c *Client) getFrom(ctx context.Context, url string, offset int64) (*http.Response, error) {
+	if c.Offline {
+		return nil, fmt.Errorf("%w: %s", ErrOffline, url)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	} else {
+		// Ask for gzip ourselves rather than rely on the transport, which
+		// a custom HTTP.Transport may not do; the big JSON files (tickers,
+		// submissions, companyfacts) shrink several times over. Ranges stay
+		// uncompressed so offsets count decoded bytes.
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	resp, err := c.doRateLimitedRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	// SEC answers 403 to requests without an identifying User-Agent, so
+	// every caller gets the explanation instead of a bare status.
+	if resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrForbidden, url)
+	}
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		if err := gunzipBody(resp); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("decoding gzip response from %s: %w", url, err)
+		}
+	}
+	return resp, nil
+}
+
+// gunzipBody makes resp read as if it had been sent uncompressed. The
+// decoded length is unknown, so ContentLength becomes -1 and size limits
+// apply only while reading: the compressed length says nothing reliable
+// about the decoded one, whatever encoder made it.
+func gunzipBody(resp *http.Response) error {
+	zr, err := gzip.NewReader(resp.Body)
+	switch {
+	case err == io.EOF:
+		// An empty body, as on some error responses.
+	case err != nil:
+		return err
+	default:
+		resp.Body = gzipBody{zr, resp.Body}
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// PadCIK renders a CIK in the 10-digit form used by EDGAR URLs.
+func PadCIK(cik int) string {
+	return fmt.Sprintf("%010d", cik)
+}
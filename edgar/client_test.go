@@ -0,0 +1,239 @@
+package edgar
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// newTestClient returns a client whose three base URLs point at a server
+// running h, with no rate limit, no ticker cache and no back-off pauses.
+func newTestClient(t *testing.T, h http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c := NewClient()
+	c.UserAgent = "Test test@example.com"
+	c.Limiter = rate.NewLimiter(rate.Inf, 1)
+	c.CacheDir = ""
+	c.SECBaseURL, c.DataBaseURL, c.SearchBaseURL = srv.URL, srv.URL+"/", srv.URL
+	return c
+}
+
+func TestBaseURLs(t *testing.T) {
+	serve := func(title string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/files/company_tickers.json":
+				w.Write([]byte(`{"0":{"cik_str":320193,"ticker":"AAPL","title":"` + title + `"}}`))
+			case "/submissions/CIK0000320193.json":
+				w.Write([]byte(`{"cik":"320193","filings":{"recent":{"form":["10-K"],"accessionNumber":["0000320193-24-000123"],` +
+					`"primaryDocument":["aapl-20240928.htm"],"filingDate":["2024-11-01"],"reportDate":["2024-09-28"]}}}`))
+			default:
+				http.NotFound(w, r)
+			}
+		})
+	}
+	// Two clients side by side must each reach their own host.
+	a, b := newTestClient(t, serve("Mirror A")), newTestClient(t, serve("Mirror B"))
+	ctx := context.Background()
+	for c, want := range map[*Client]string{a: "Mirror A", b: "Mirror B"} {
+		co, err := c.LookupTicker(ctx, "AAPL")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if co.Title != want {
+			t.Errorf("LookupTicker via %s: title %q, want %q", c.SECBaseURL, co.Title, want)
+		}
+		filings, err := c.FetchFilings(ctx, co.CIK, FetchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(filings) != 1 || filings[0].Accession != "0000320193-24-000123" {
+			t.Errorf("FetchFilings via %s = %+v", c.DataBaseURL, filings)
+		}
+		if url := c.ArchiveURL(co.CIK, "0000320193-24-000123", "x.htm"); !strings.HasPrefix(url, c.SECBaseURL+"/Archives/") {
+			t.Errorf("ArchiveURL = %s, want it on %s", url, c.SECBaseURL)
+		}
+	}
+	if url := ArchiveURL(320193, "0000320193-24-000123", "x.htm"); url != "https://www.sec.gov/Archives/edgar/data/320193/000032019324000123/x.htm" {
+		t.Errorf("package ArchiveURL = %s", url)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		val  string
+		want time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{" 7 ", 7 * time.Second},
+		{"0", 0},
+		{"-5", 0},
+		{"Fri, 01 Nov 2024 12:00:30 GMT", 30 * time.Second},
+		{"Friday, 01-Nov-24 12:01:00 GMT", time.Minute}, // RFC 850
+		{"Fri Nov  1 12:00:10 2024", 10 * time.Second},  // ANSI C
+		{"Fri, 01 Nov 2024 11:59:00 GMT", 0},            // in the past
+		{"Fri, 01 Nov 2024 12:00:00 GMT", 0},            // now
+		{"soon", 0},
+		{"1.5", 0},
+		{"Fri, 32 Nov 2024 12:00:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.val, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	windows := map[int]time.Duration{
+		1:  5 * time.Second,
+		2:  10 * time.Second,
+		3:  20 * time.Second,
+		4:  40 * time.Second,
+		5:  time.Minute,
+		9:  time.Minute,
+		64: time.Minute, // no overflow from the shift
+	}
+	for attempt, want := range windows {
+		var window time.Duration
+		got := backoff(attempt, func(w time.Duration) time.Duration { window = w; return w - 1 })
+		if window != want || got != want-1 {
+			t.Errorf("backoff(%d): window %s, delay %s; want window %s", attempt, window, got, want)
+		}
+	}
+	for attempt := 1; attempt <= 6; attempt++ {
+		for range 200 {
+			if d := backoff(attempt, nil); d < 0 || d >= windows[min(attempt, 5)] {
+				t.Fatalf("backoff(%d) = %s, outside [0, %s)", attempt, d, windows[min(attempt, 5)])
+			}
+		}
+	}
+}
+
+func TestMaxTotalRetries(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	c.MaxTotalRetries = 2
+	c.jitter = func(time.Duration) time.Duration { return 0 }
+
+	_, err := c.get(context.Background(), c.secURL()+"/x")
+	if !errors.Is(err, ErrRetryBudget) {
+		t.Fatalf("first request: err = %v, want ErrRetryBudget", err)
+	}
+	// Two retries are allowed, so the third failure ends it.
+	if n := hits.Load(); n != 3 {
+		t.Errorf("first request reached the server %d times, want 3", n)
+	}
+	_, err = c.get(context.Background(), c.secURL()+"/y")
+	if n := hits.Load(); !errors.Is(err, ErrRetryBudget) || n != 3 {
+		t.Errorf("after the budget: err = %v, hits = %d; want ErrRetryBudget without a request", err, n)
+	}
+}
+
+func gzipped(t *testing.T, data []byte, level int, name string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&b, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Name = name
+	zw.Write(data)
+	zw.Close()
+	return b.Bytes()
+}
+
+// paddedGzip encodes data as a gzip stream no zlib would write: thousands
+// of empty stored blocks before one stored block holding data, so the body
+// is far longer than what it decodes to.
+func paddedGzip(data []byte, empty int) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 0xff})
+	for range empty {
+		b.Write([]byte{0, 0, 0, 0xff, 0xff})
+	}
+	n := uint16(len(data))
+	b.Write([]byte{1, byte(n), byte(n >> 8), ^byte(n), ^byte(n >> 8)})
+	b.Write(data)
+	binary.Write(&b, binary.LittleEndian, crc32.ChecksumIEEE(data))
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	return b.Bytes()
+}
+
+// TestSizeLimitGzip checks that a compressed response is held to the cap
+// by what it decodes to, never by its compressed length: a long body that
+// decodes small is read, one that decodes large is stopped while reading.
+func TestSizeLimitGzip(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(3, 4))
+	noise := make([]byte, 200_000)
+	for i := range noise {
+		noise[i] = byte(rnd.Uint32())
+	}
+	big := gzipped(t, noise, gzip.BestSpeed, "")
+	bomb := gzipped(t, bytes.Repeat([]byte("<p>x</p>"), 250_000), gzip.BestCompression, "")
+	small := []byte("<html><body><p>a small filing</p></body></html>")
+	padded := paddedGzip(small, 50_000)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := big
+		switch {
+		case strings.HasSuffix(r.URL.Path, "bomb.htm"):
+			body = bomb
+		case strings.HasSuffix(r.URL.Path, "padded.htm"):
+			body = padded
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+	}))
+	c.MaxFileBytes = 100_000
+	ctx := context.Background()
+
+	if len(padded) <= int(c.MaxFileBytes) {
+		t.Fatalf("padded body of %d bytes is within the cap", len(padded))
+	}
+	for _, name := range []string{"big.htm", "bomb.htm"} {
+		_, _, err := c.fetchDocument(ctx, c.secURL()+"/"+name, 0)
+		if !errors.Is(err, ErrTooLarge) || !strings.Contains(err.Error(), "more than") {
+			t.Errorf("fetchDocument %s: err = %v, want ErrTooLarge while reading", name, err)
+		}
+	}
+	doc, _, err := c.fetchDocument(ctx, c.secURL()+"/padded.htm", 0)
+	if err != nil || !bytes.Equal(doc, small) {
+		t.Errorf("fetchDocument of a long gzip body that decodes small: %q, %v", doc, err)
+	}
+
+	c.FS = newMemFS()
+	name := filepath.Join(t.TempDir(), "big.htm")
+	for _, url := range []string{c.secURL() + "/big.htm", c.secURL() + "/bomb.htm"} {
+		if _, err := c.saveFile(ctx, url, name, 0); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("saveFile %s: err = %v, want ErrTooLarge", url, err)
+		}
+		if _, err := c.FS.Stat(name + ".part"); err == nil {
+			t.Errorf("saveFile %s left the part file behind", url)
+		}
+	}
+	if _, err := c.saveFile(ctx, c.secURL()+"/padded.htm", name, 0); err != nil {
+		t.Errorf("saveFile of a long gzip body that decodes small: %v", err)
+	}
+}
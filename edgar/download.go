@@ -0,0 +1,1058 @@
+package edgar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jaytaylor/html2text"
+)
+
+// DownloadOptions controls how a filing is written to disk.
+type DownloadOptions struct {
+	// Format is "text" (converted .txt, the default), "html" (original .htm),
+	// "pdf" (the converted text laid out as a .pdf) or "complete" (the full
+	// SGML submission, every document included, as .complete.txt).
+	Format string
+	// MaxDocBytes rejects larger documents with ErrTooLarge; 0 means no limit.
+	MaxDocBytes int64
+	// Financials also writes <name>_financials.json from inline XBRL.
+	Financials bool
+	// Section keeps only one item of the text, e.g. "7" or "1A" (see
+	// ExtractSection), in a file named <name>_item<N>. Not valid with "html".
+	Section string
+	// NameTemplate, from ParseNameTemplate, replaces the FilingPath naming.
+	NameTemplate *template.Template
+	// Text tunes the HTML to text conversion of the "text" and "pdf" formats.
+	Text TextOptions
+	// GroupByYear puts each file in a YYYY subdirectory of dir (see YearDir).
+	GroupByYear bool
+	// KeepHTML also saves the original document as .htm next to the text or
+	// pdf, from the same download. Both must be on disk for a skip.
+	KeepHTML bool
+	// State, if set, skips filings it records as saved in the same format,
+	// wherever that was, and records each filing that is saved or found.
+	State *State
+}
+
+// TextOptions control what the text conversion keeps of the HTML. The zero
+// value gives the leanest text: link targets are dropped and tables are
+// flattened into running text.
+type TextOptions struct {
+	// KeepLinks appends each link's URL, e.g. "Exhibit 21 (https://…)".
+	KeepLinks bool
+	// KeepTables writes each table row on its own line with "|" between
+	// the cells, so financial statements keep their columns.
+	KeepTables bool
+	// CollapseWhitespace also trims trailing spaces and tabs from every line,
+	// which keeps diffs between years of the same company clean. Runs of
+	// blank lines are collapsed to one either way.
+	CollapseWhitespace bool
+}
+
+// NameData is what a NameTemplate is executed with.
+type NameData struct {
+	Ticker    string // the company's ticker, else the download directory name
+	CIK       string
+	Form      string
+	Date      string // filing date
+	Period    string // report date; empty for some forms
+	Accession string
+}
+
+// ParseNameTemplate parses a text/template for file names without their
+// extension, e.g. "{{.Ticker}}_{{.Form}}_{{.Period}}", and rejects templates
+// that do not give a plain file name. Names should stay unique per filing;
+// include {{.Accession}} when in doubt.
+func ParseNameTemplate(s string) (*template.Template, error) {
+	t, err := template.New("filename").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	_, err = renderName(t, NameData{Ticker: "AAPL", CIK: "0000320193", Form: "10-K",
+		Date: "2024-11-01", Period: "2024-09-28", Accession: "0000320193-24-000123"})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func renderName(t *template.Template, d NameData) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, d); err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(b.String())
+	if name == "" || strings.Contains(name, "..") || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("file name template gives %q, which is not a plain file name", name)
+	}
+	return name, nil
+}
+
+// stem is the path of f's file in dir without section suffix or extension.
+func (o DownloadOptions) stem(dir string, f Filing) (string, error) {
+	ticker := f.Company.Ticker
+	if ticker == "" {
+		ticker = strings.TrimPrefix(filepath.Base(dir), "filings_")
+	}
+	if o.GroupByYear {
+		dir = YearDir(dir, f)
+	}
+	if o.NameTemplate == nil {
+		return strings.TrimSuffix(FilingPath(dir, f, o.Format), FormatExt(o.Format)), nil
+	}
+	name, err := renderName(o.NameTemplate, NameData{
+		Ticker:    safeName(ticker),
+		CIK:       PadCIK(f.Company.CIK),
+		Form:      safeName(f.Form),
+		Date:      f.FilingDate,
+		Period:    f.ReportDate,
+		Accession: f.Accession,
+	})
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Path is where Download writes f inside dir with these options. Should
+// NameTemplate fail for f, Download reports the error and Path falls back to
+// the default name.
+func (o DownloadOptions) Path(dir string, f Filing) string {
+	stem, err := o.stem(dir, f)
+	if err != nil {
+		o.NameTemplate = nil
+		stem, _ = o.stem(dir, f)
+	}
+	if o.Section != "" {
+		stem += "_item" + strings.ToLower(o.Section)
+	}
+	return stem + FormatExt(o.Format)
+}
+
+// htmlPath is where KeepHTML saves the original document of f.
+func (o DownloadOptions) htmlPath(dir string, f Filing) string {
+	o.Format, o.Section = "html", ""
+	return o.Path(dir, f)
+}
+
+// YearDir is the subdirectory of dir that GroupByYear puts f in: the year of
+// its report date, so a FY2022 10-K filed in 2023 lands in 2022, or of its
+// filing date when EDGAR has no report date.
+func YearDir(dir string, f Filing) string {
+	date := f.ReportDate
+	if len(date) < 4 {
+		date = f.FilingDate
+	}
+	if len(date) < 4 {
+		return dir
+	}
+	return filepath.Join(dir, date[:4])
+}
+
+// docBaseName returns the file part of a primaryDocument value. EDGAR may
+// report nested paths like "subdir/doc.htm", which are valid in the archive
+// URL but must not leak into local file names.
+func docBaseName(docName string) string {
+	return path.Base(strings.ReplaceAll(docName, "\\", "/"))
+}
+
+// FormatExt maps an output format to its file extension.
+func FormatExt(format string) string {
+	switch format {
+	case "html":
+		return ".htm"
+	case "pdf":
+		return ".pdf"
+	case "complete":
+		return ".complete.txt"
+	}
+	return ".txt"
+}
+
+// ArchiveURL points at a file inside a filing's EDGAR archive directory.
+// accNum should have passed ParseAccession; anything else is used as given,
+// minus dashes.
+func ArchiveURL(cik int, accNum, name string) string {
+	return archiveURL(DefaultSECBaseURL, cik, accNum, name)
+}
+
+// ArchiveURL is the package ArchiveURL on the client's SECBaseURL.
+func (c *Client) ArchiveURL(cik int, accNum, name string) string {
+	return archiveURL(c.secURL(), cik, accNum, name)
+}
+
+func archiveURL(base string, cik int, accNum, name string) string {
+	_, plain, err := ParseAccession(accNum)
+	if err != nil {
+		plain = strings.ReplaceAll(accNum, "-", "")
+	}
+	return fmt.Sprintf("%s/Archives/edgar/data/%d/%s/%s", base, cik, plain, name)
+}
+
+// unsafeChars are path separators and characters some file systems reject.
+var unsafeChars = strings.NewReplacer("/", "-", "\\", "-", ":", "-", "*", "-", "?", "-", "\"", "-", "<", "-", ">", "-", "|", "-")
+
+// safeName makes a form type such as "10-K/A" or "S-1/A" usable inside a
+// file name ("10-K-A"), so it can never create a subdirectory.
+func safeName(s string) string {
+	return unsafeChars.Replace(strings.TrimSpace(s))
+}
+
+// FilingPath is where Download writes a filing inside dir:
+// <filing date>_<form>_<period>_<accession>, the period (report date) being
+// left out when EDGAR has none. The accession number keeps two filings of
+// the same form on the same day apart.
+func FilingPath(dir string, f Filing, format string) string {
+	form := safeName(f.Form)
+	if f.ReportDate == "" {
+		return filepath.Join(dir, fmt.Sprintf("%s_%s_%s%s", f.FilingDate, form, f.Accession, FormatExt(format)))
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s_%s_%s_%s%s", f.FilingDate, form, f.ReportDate, f.Accession, FormatExt(format)))
+}
+
+// legacyPaths are the names earlier versions used: date_form_accession,
+// and date_form before accession numbers were part of file names.
+func legacyPaths(dir string, f Filing, format string) []string {
+	form := safeName(f.Form)
+	return []string{
+		filepath.Join(dir, fmt.Sprintf("%s_%s_%s%s", f.FilingDate, form, f.Accession, FormatExt(format))),
+		filepath.Join(dir, fmt.Sprintf("%s_%s%s", f.FilingDate, form, FormatExt(format))),
+	}
+}
+
+// haveFiling reports whether f is already on disk, under its current name
+// or a legacy one, with a sidecar for the same accession. Checking the
+// accession keeps a template that names two filings alike from skipping one.
+func haveFiling(fsys FS, dir string, f Filing, opts DownloadOptions) bool {
+	names := []string{opts.Path(dir, f)}
+	if opts.Section == "" && opts.NameTemplate == nil {
+		if opts.GroupByYear {
+			// Saved by a run without GroupByYear.
+			names = append(names, FilingPath(dir, f, opts.Format))
+		}
+		names = append(names, legacyPaths(dir, f, opts.Format)...)
+	}
+	for _, name := range names {
+		if !fileExists(fsys, name) {
+			continue
+		}
+		for _, sidecar := range []string{sidecarPath(name), legacySidecarPath(name)} {
+			data, err := fsys.ReadFile(sidecar)
+			var meta Sidecar
+			if err == nil && json.Unmarshal(data, &meta) == nil && meta.Accession == f.Accession {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Download fetches the primary document of f into dir as text, together
+// with its JSON sidecar, using DefaultClient.
+func Download(ctx context.Context, f Filing, dir string) error {
+	_, err := DefaultClient.Download(ctx, f, dir, DownloadOptions{})
+	return err
+}
+
+// Download fetches the primary document of f into dir and returns the number
+// of bytes written. It returns ErrFileExists, without downloading, when the
+// filing and its sidecar are already there and the file still matches its
+// manifest checksum; a damaged file is downloaded again. With opts.State it
+// also returns ErrFileExists for a filing the state file holds.
+func (c *Client) Download(ctx context.Context, f Filing, dir string, opts DownloadOptions) (int64, error) {
+	if opts.State == nil {
+		return c.download(ctx, f, dir, opts)
+	}
+	if e, ok := opts.State.Lookup(f, opts); ok && !c.Offline {
+		return 0, fmt.Errorf("%w: saved earlier as %s", ErrFileExists, e.Path)
+	}
+	n, err := c.download(ctx, f, dir, opts)
+	if err == nil || errors.Is(err, ErrFileExists) {
+		if err := opts.State.Record(f, opts.Path(dir, f), opts); err != nil {
+			c.Logger.Warn("updating state file", "err", err)
+		}
+	}
+	return n, err
+}
+
+func (c *Client) download(ctx context.Context, f Filing, dir string, opts DownloadOptions) (int64, error) {
+	acc, _, err := ParseAccession(f.Accession)
+	if err != nil {
+		return 0, err
+	}
+	f.Accession = acc
+	if opts.Section != "" {
+		if opts.Format == "html" || opts.Format == "complete" {
+			return 0, errors.New("a section can only be extracted from text or pdf output")
+		}
+		item, err := ParseItem(opts.Section)
+		if err != nil {
+			return 0, err
+		}
+		opts.Section = item
+	}
+	stem, err := opts.stem(dir, f)
+	if err != nil {
+		return 0, err
+	}
+	filename := opts.Path(dir, f)
+
+	fsys := c.fs()
+	keepHTML := opts.KeepHTML && opts.Format != "html" && opts.Format != "complete"
+	if haveFiling(fsys, dir, f, opts) {
+		switch {
+		case !intact(fsys, dir, filename):
+			c.Logger.Warn("checksum mismatch, downloading again", "file", filename)
+		case !keepHTML:
+			return 0, ErrFileExists
+		case fileExists(fsys, opts.htmlPath(dir, f)) && intact(fsys, dir, opts.htmlPath(dir, f)):
+			return 0, ErrFileExists
+		}
+	}
+	if opts.GroupByYear {
+		if err := fsys.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return 0, err
+		}
+	}
+
+	if !c.Offline && containsFold(FundForms, BaseForm(f.Form)) {
+		c.fundSeries(ctx, &f)
+	}
+
+	if opts.Format == "complete" {
+		if c.Offline {
+			return 0, fmt.Errorf("%w: complete submission of %s", ErrOffline, f.Accession)
+		}
+		// The dissemination file is <accession>.txt in the filing directory.
+		url := c.ArchiveURL(f.Company.CIK, f.Accession, f.Accession+".txt")
+		return c.saveVerbatim(ctx, dir, filename, url, f, opts.MaxDocBytes)
+	}
+
+	if !c.Offline && f.Document == "" {
+		if f.Document, err = c.primaryDocument(ctx, f); err != nil {
+			return 0, err
+		}
+	}
+	if opts.Format == "html" && !opts.Financials && !c.Offline {
+		// Nothing to convert, so the document need not fit in memory.
+		return c.saveVerbatim(ctx, dir, filename, c.ArchiveURL(f.Company.CIK, f.Accession, f.Document), f, opts.MaxDocBytes)
+	}
+
+	var htmlBytes []byte
+	contentType := ""
+	if c.Offline {
+		htmlBytes, err = readSaved(fsys, dir, f, opts)
+	} else {
+		htmlBytes, contentType, err = c.fetchDocument(ctx, c.ArchiveURL(f.Company.CIK, f.Accession, f.Document), opts.MaxDocBytes)
+	}
+	if err != nil {
+		return 0, err
+	}
+	url := c.ArchiveURL(f.Company.CIK, f.Accession, f.Document)
+
+	if opts.Financials {
+		if err := writeFinancials(fsys, string(toUTF8(htmlBytes, contentType)), stem+"_financials.json"); err != nil {
+			return 0, fmt.Errorf("writing financials: %w", err)
+		}
+	}
+
+	if opts.Format == "html" {
+		return writeFiling(fsys, dir, filename, htmlBytes, newSidecar(f, url))
+	}
+	var htmlWritten int64
+	if keepHTML && !(c.Offline && fileExists(fsys, opts.htmlPath(dir, f))) {
+		if htmlWritten, err = writeFiling(fsys, dir, opts.htmlPath(dir, f), htmlBytes, newSidecar(f, url)); err != nil {
+			return 0, err
+		}
+	}
+
+	finalContent, err := convert(f, strings.TrimPrefix(filepath.Base(dir), "filings_"), htmlBytes, contentType, opts)
+	if err != nil {
+		return 0, err
+	}
+	n, err := writeFiling(fsys, dir, filename, finalContent, newSidecar(f, url))
+	return n + htmlWritten, err
+}
+
+// Render returns what Download would write for f with opts, without
+// touching the disk: no directory, sidecar, manifest or skip check. The
+// company in the text header is the ticker, else "CIK" and the padded CIK.
+// Financials, KeepHTML, GroupByYear, NameTemplate and State do not apply.
+func (c *Client) Render(ctx context.Context, f Filing, opts DownloadOptions) ([]byte, error) {
+	acc, _, err := ParseAccession(f.Accession)
+	if err != nil {
+		return nil, err
+	}
+	f.Accession = acc
+	if c.Offline {
+		return nil, fmt.Errorf("%w: rendering %s", ErrOffline, f.Accession)
+	}
+	if opts.Section != "" {
+		if opts.Format == "html" || opts.Format == "complete" {
+			return nil, errors.New("a section can only be extracted from text or pdf output")
+		}
+		if opts.Section, err = ParseItem(opts.Section); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Format == "complete" {
+		data, _, err := c.fetchDocument(ctx, c.ArchiveURL(f.Company.CIK, f.Accession, f.Accession+".txt"), opts.MaxDocBytes)
+		return data, err
+	}
+	if f.Document == "" {
+		if f.Document, err = c.primaryDocument(ctx, f); err != nil {
+			return nil, err
+		}
+	}
+	htmlBytes, contentType, err := c.fetchDocument(ctx, c.ArchiveURL(f.Company.CIK, f.Accession, f.Document), opts.MaxDocBytes)
+	if err != nil || opts.Format == "html" {
+		return htmlBytes, err
+	}
+	company := f.Company.Ticker
+	if company == "" {
+		company = "CIK" + PadCIK(f.Company.CIK)
+	}
+	return convert(f, company, htmlBytes, contentType, opts)
+}
+
+// convert turns the primary document of f into the text, with its metadata
+// header, or the pdf that opts asks for. company names the filer in the
+// header.
+func convert(f Filing, company string, htmlBytes []byte, contentType string, opts DownloadOptions) ([]byte, error) {
+	text, err := htmlToText(string(toUTF8(htmlBytes, contentType)), opts.Text)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Section != "" {
+		if text, err = ExtractSection(text, opts.Section); err != nil {
+			return nil, err
+		}
+	}
+
+	// 8. Build the final output with Metadata at the TOP
+	// Using a distinct header helps the AI cite its sources chronologically.
+	co := f.Company
+	header := fmt.Sprintf("--- METADATA ---\nCOMPANY: %s\nCIK: %s\n", company, PadCIK(co.CIK))
+	if co.SeriesID != "" {
+		header += fmt.Sprintf("SERIES: %s\nCLASS: %s\n", co.SeriesID, co.ClassID)
+	}
+	header += fmt.Sprintf("FORM: %s\nDATE: %s\n", f.Form, f.FilingDate)
+	if f.ReportDate != "" {
+		header += fmt.Sprintf("PERIOD: %s\n", f.ReportDate)
+	}
+	if opts.Section != "" {
+		header += fmt.Sprintf("SECTION: Item %s\n", opts.Section)
+	}
+	header += "----------------\n\n"
+
+	finalContent := []byte(header + text)
+	if opts.Format == "pdf" {
+		finalContent = textToPDF(string(finalContent))
+	}
+	return finalContent, nil
+}
+
+// saveVerbatim streams url to filename (see saveFile), then writes the
+// sidecar and records the file in the manifest of dir, like writeFiling.
+func (c *Client) saveVerbatim(ctx context.Context, dir, filename, url string, f Filing, maxBytes int64) (int64, error) {
+	entry, err := c.saveFile(ctx, url, filename, maxBytes)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeSidecar(c.fs(), filename, newSidecar(f, url)); err != nil {
+		return 0, err
+	}
+	entry.Accession = f.Accession
+	if err := recordEntry(c.fs(), dir, filename, entry); err != nil {
+		return 0, fmt.Errorf("updating manifest: %w", err)
+	}
+	return entry.Size, nil
+}
+
+// fileLimit combines a per-call byte limit with MaxFileBytes; 0 means none.
+func (c *Client) fileLimit(maxBytes int64) int64 {
+	if c.MaxFileBytes > 0 && (maxBytes <= 0 || c.MaxFileBytes < maxBytes) {
+		return c.MaxFileBytes
+	}
+	return maxBytes
+}
+
+// fetchDocument downloads a document, refusing anything above maxBytes
+// or MaxFileBytes when set, and returns it with its Content-Type.
+func (c *Client) fetchDocument(ctx context.Context, url string, maxBytes int64) ([]byte, string, error) {
+	maxBytes = c.fileLimit(maxBytes)
+	resp, err := c.get(ctx, url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("status %d for %s", resp.StatusCode, url)
+	}
+
+	body := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		if resp.ContentLength > maxBytes {
+			return nil, "", fmt.Errorf("%w (%d bytes)", ErrTooLarge, resp.ContentLength)
+		}
+		// Content-Length is optional, and unknown for a gzip body, so also
+		// stop reading one byte past the cap.
+		body = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading SEC filing body: %w", err)
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return nil, "", fmt.Errorf("%w (more than %d bytes)", ErrTooLarge, maxBytes)
+	}
+	if err := checkDocument(data); err != nil {
+		return nil, "", fmt.Errorf("%w: %s", err, url)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// ProbeDocument checks that the primary document of f can be fetched, with a
+// HEAD request through the rate limiter, and returns the status EDGAR gave
+// and the URL. Nothing is read or written; a missing Document is looked up
+// in the filing index first.
+func (c *Client) ProbeDocument(ctx context.Context, f Filing) (int, string, error) {
+	if c.Offline {
+		return 0, "", fmt.Errorf("%w: probing %s", ErrOffline, f.Accession)
+	}
+	if f.Document == "" {
+		doc, err := c.primaryDocument(ctx, f)
+		if err != nil {
+			return 0, "", err
+		}
+		f.Document = doc
+	}
+	url := c.ArchiveURL(f.Company.CIK, f.Accession, f.Document)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, url, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	resp, err := c.doRateLimitedRequest(req)
+	if err != nil {
+		return 0, url, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, url, nil
+}
+
+// notDocumentMarkers appear near the top of the pages SEC serves, with
+// status 200, for a directory or a document that is not there.
+var notDocumentMarkers = []string{
+	"<title>index of /",
+	"<title>edgar filing documents",
+	"<title>sec.gov | page not found",
+	"<title>sec.gov | request rate threshold exceeded",
+	"<title>sec.gov | your request originates from an undeclared automated tool",
+	"<title>file not found",
+}
+
+// checkDocument returns ErrNotDocument when the start of doc is one of SEC's
+// directory listings or error pages rather than a filing.
+func checkDocument(doc []byte) error {
+	head := strings.ToLower(string(doc[:min(len(doc), 4096)]))
+	for _, m := range notDocumentMarkers {
+		if strings.Contains(head, m) {
+			return ErrNotDocument
+		}
+	}
+	return nil
+}
+
+// saveFile streams url into name, for documents that are kept verbatim and
+// can be large. The body goes to name+".part" first; when reading it fails
+// part-way, the next attempt, in this call or a later run, asks for only the
+// rest with a Range request. Archive documents never change once filed, so
+// the pieces fit together. name appears only once it is complete.
+func (c *Client) saveFile(ctx context.Context, url, name string, maxBytes int64) (ManifestEntry, error) {
+	maxBytes = c.fileLimit(maxBytes)
+	part := name + ".part"
+	for attempt := 1; ; attempt++ {
+		resumable, err := c.fetchPart(ctx, url, part, maxBytes)
+		if err == nil {
+			break
+		}
+		if !resumable || attempt == MaxRetries || ctx.Err() != nil {
+			return ManifestEntry{}, err
+		}
+		c.Logger.Warn("download interrupted, resuming", "url", url, "attempt", attempt, "err", err)
+	}
+	fsys := c.fs()
+	if err := checkPart(fsys, part); err != nil {
+		fsys.Remove(part)
+		return ManifestEntry{}, fmt.Errorf("%w: %s", err, url)
+	}
+	if err := fsys.Rename(part, name); err != nil {
+		return ManifestEntry{}, err
+	}
+	return fileEntry(fsys, name)
+}
+
+// checkPart runs checkDocument on the start of a downloaded file.
+func checkPart(fsys FS, part string) error {
+	f, err := fsys.Open(part)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	head := make([]byte, 4096)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	return checkDocument(head[:n])
+}
+
+// fetchPart appends the rest of url to part. resumable reports that the
+// body broke off and another attempt can pick up where it stopped.
+func (c *Client) fetchPart(ctx context.Context, url, part string, maxBytes int64) (resumable bool, err error) {
+	fsys := c.fs()
+	var offset int64
+	if fi, err := fsys.Stat(part); err == nil {
+		offset = fi.Size()
+	}
+	resp, err := c.getFrom(ctx, url, offset)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// A fresh download, or a server that ignored the Range: start over.
+		offset = 0
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset > 0 {
+			// The part already holds the whole document.
+			return false, nil
+		}
+		fallthrough
+	default:
+		return false, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if maxBytes > 0 && resp.ContentLength > 0 && offset+resp.ContentLength > maxBytes {
+		fsys.Remove(part)
+		return false, fmt.Errorf("%w (%d bytes)", ErrTooLarge, offset+resp.ContentLength)
+	}
+
+	f, err := fsys.OpenFile(part, flags, 0644)
+	if err != nil {
+		return false, err
+	}
+	body := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		body = io.LimitReader(resp.Body, maxBytes-offset+1)
+	}
+	n, err := io.Copy(f, body)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		return false, cerr
+	}
+	if err != nil {
+		return true, fmt.Errorf("reading body: %w", err)
+	}
+	if maxBytes > 0 && offset+n > maxBytes {
+		fsys.Remove(part)
+		return false, fmt.Errorf("%w (more than %d bytes)", ErrTooLarge, maxBytes)
+	}
+	return false, nil
+}
+
+// htmlToText converts a filing to plain text tuned for LLM ingestion.
+func htmlToText(doc string, opts TextOptions) (string, error) {
+	// PrettyTables stays OFF unless asked for: running text tokenizes better
+	conv := html2text.Options{OmitLinks: !opts.KeepLinks}
+	if opts.KeepTables {
+		// Borderless, unwrapped rows: "Revenue | $ | 1,234"
+		conv.PrettyTables = true
+		conv.PrettyTablesOptions = &html2text.PrettyTablesOptions{
+			ColumnSeparator: "|",
+			RowSeparator:    "-",
+			CenterSeparator: "|",
+			NewLine:         "\n",
+		}
+	}
+	text, err := html2text.FromString(doc, conv)
+	if err != nil {
+		return "", err
+	}
+
+	// 1. Clean "Non-Breaking" Spaces (SEC filings are full of these)
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+
+	// 2. XBRL "Soup" Stripper
+	// Removes lines starting with technical schema links (http, xbrli, etc.)
+	reSoup := regexp.MustCompile(`(?m)^(http|https|xmlns|xbrli):.*$`)
+	text = reSoup.ReplaceAllString(text, "")
+
+	// 3. Fix "Drifting" Symbols (Keeps currencies and negatives connected)
+	// Joins $ and ( to the numbers they belong to
+	// (but never across a table's "|" column separator)
+	// Repeated because a match consumes the next symbol, as in "$ ( 56".
+	reDrift := regexp.MustCompile(`([$\(\-])\s+([^\s|])`)
+	for prev := ""; prev != text; {
+		prev, text = text, reDrift.ReplaceAllString(text, "$1$2")
+	}
+	reParensClose := regexp.MustCompile(`([^\s|])\s+\)`)
+	text = reParensClose.ReplaceAllString(text, "$1)")
+
+	// 4. Kill lines that contain ONLY whitespace (spaces/tabs)
+	// This allows the next step to catch "empty" lines that aren't actually empty.
+	reOnlyWhitespace := regexp.MustCompile(`(?m)^[ \t]+$`)
+	text = reOnlyWhitespace.ReplaceAllString(text, "")
+	if opts.CollapseWhitespace {
+		// ...and, on request, the trailing whitespace of every other line.
+		reTrailing := regexp.MustCompile(`(?m)[ \t]+$`)
+		text = reTrailing.ReplaceAllString(text, "")
+	}
+
+	// 5. Page Number Stripping (removes standalone digits or "Page X")
+	rePage := regexp.MustCompile(`(?m)^(\s*\d+\s*|\s*[Pp]age\s+\d+\s*)$`)
+	text = rePage.ReplaceAllString(text, "")
+
+	// 6. Collapse multiple newlines (3+ becomes 2)
+	// NotebookLM prefers double-newlines for distinct context blocks.
+	reMultiLine := regexp.MustCompile(`\n{3,}`)
+	text = reMultiLine.ReplaceAllString(text, "\n\n")
+
+	// 7. Trim leading/trailing whitespace
+	return strings.TrimSpace(text), nil
+}
+
+// FilingIndex mirrors the index.json EDGAR serves for a filing directory.
+type FilingIndex struct {
+	Directory struct {
+		Item []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+			Size string `json:"size"`
+		} `json:"item"`
+	} `json:"directory"`
+}
+
+// FilingIndex lists every file in the archive directory of f.
+func (c *Client) FilingIndex(ctx context.Context, f Filing) (FilingIndex, error) {
+	if _, _, err := ParseAccession(f.Accession); err != nil {
+		return FilingIndex{}, err
+	}
+	resp, err := c.get(ctx, c.ArchiveURL(f.Company.CIK, f.Accession, "index.json"))
+	if err != nil {
+		return FilingIndex{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return FilingIndex{}, fmt.Errorf("filing index: status %d", resp.StatusCode)
+	}
+	var idx FilingIndex
+	if err := json.NewDecoder(resp.Body).Decode(&idx); err != nil {
+		return FilingIndex{}, fmt.Errorf("decoding filing index: %w", err)
+	}
+	return idx, nil
+}
+
+// primaryDocument finds the main document of a filing whose submissions entry
+// has no primaryDocument. index.json lists the files but not their types, so
+// when there are several candidates the SGML headers decide: the document
+// typed as the filing's form wins, else the first one listed that is not an
+// exhibit.
+func (c *Client) primaryDocument(ctx context.Context, f Filing) (string, error) {
+	idx, err := c.FilingIndex(ctx, f)
+	if err != nil {
+		return "", err
+	}
+	var candidates []string
+	for _, it := range idx.Directory.Item {
+		name := strings.ToLower(it.Name)
+		if skipIndexItem(it.Type, name, f.Accession) {
+			continue
+		}
+		switch path.Ext(name) {
+		case ".htm", ".html", ".txt":
+			candidates = append(candidates, it.Name)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no primary document in filing %s", f.Accession)
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	resp, err := c.get(ctx, c.ArchiveURL(f.Company.CIK, f.Accession, f.Accession+"-index-headers.html"))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return candidates[0], nil
+	}
+	headers, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading filing headers: %w", err)
+	}
+	docs := reDocHeader.FindAllStringSubmatch(html.UnescapeString(string(headers)), -1)
+	for _, d := range docs {
+		if strings.EqualFold(d[1], f.Form) && slices.Contains(candidates, d[2]) {
+			return d[2], nil
+		}
+	}
+	// Exhibits are never the primary document, even when nothing else is left.
+	candidates = slices.DeleteFunc(candidates, func(name string) bool {
+		return slices.ContainsFunc(docs, func(d []string) bool {
+			return d[2] == name && strings.HasPrefix(strings.ToUpper(d[1]), "EX-")
+		})
+	})
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no primary document in filing %s, only exhibits", f.Accession)
+	}
+	for _, d := range docs {
+		if slices.Contains(candidates, d[2]) {
+			return d[2], nil
+		}
+	}
+	return candidates[0], nil
+}
+
+// skipIndexItem reports whether an index.json entry is not a document of
+// the filing: a folder, one of EDGAR's -index pages, or <accession>.txt, the
+// complete submission that repeats every document.
+func skipIndexItem(typ, name, acc string) bool {
+	name = strings.ToLower(name)
+	return typ == "folder.gif" || strings.Contains(name, "-index") || name == acc+".txt"
+}
+
+// reDocHeader matches the TYPE and FILENAME of each <DOCUMENT> in the SGML
+// headers of a filing.
+var reDocHeader = regexp.MustCompile(`(?s)<TYPE>([^\s<]+).*?<FILENAME>([^\s<]+)`)
+
+var (
+	reSeriesID = regexp.MustCompile(`<SERIES-ID>\s*(S\d+)`)
+	reClassID  = regexp.MustCompile(`<CLASS-CONTRACT-ID>\s*(C\d+)`)
+)
+
+// fundSeries sets the series and class of a fund filing from its SGML
+// header, which lists those the filing covers. The identifiers from
+// company_tickers_mf.json stay when the header names them too, or cannot be
+// read; a filing of a single series gets that series even when the ticker
+// listing said otherwise.
+func (c *Client) fundSeries(ctx context.Context, f *Filing) {
+	resp, err := c.get(ctx, c.ArchiveURL(f.Company.CIK, f.Accession, f.Accession+"-index-headers.html"))
+	if err == nil && resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		err = fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if err != nil {
+		c.Logger.Warn("no series in the filing header", "accession", f.Accession, "err", err)
+		return
+	}
+	headers, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+	if err != nil {
+		c.Logger.Warn("no series in the filing header", "accession", f.Accession, "err", err)
+		return
+	}
+
+	// Each <SERIES> block holds its id and the ids of its classes.
+	classes := make(map[string][]string)
+	var series []string
+	blocks := strings.Split(html.UnescapeString(string(headers)), "<SERIES>")
+	for _, block := range blocks[1:] {
+		m := reSeriesID.FindStringSubmatch(block)
+		if m == nil {
+			continue
+		}
+		if _, ok := classes[m[1]]; !ok {
+			series = append(series, m[1])
+		}
+		for _, cm := range reClassID.FindAllStringSubmatch(block, -1) {
+			classes[m[1]] = append(classes[m[1]], cm[1])
+		}
+	}
+
+	co := &f.Company
+	switch {
+	case len(series) == 0:
+		return
+	case slices.Contains(series, co.SeriesID):
+		// The ticker's own series, perhaps among others of the trust.
+	case len(series) == 1:
+		co.SeriesID = series[0]
+	default:
+		// Several series, none of them the ticker's: no single answer.
+		co.SeriesID, co.ClassID = "", ""
+		return
+	}
+	switch ids := classes[co.SeriesID]; {
+	case slices.Contains(ids, co.ClassID):
+	case len(ids) == 1:
+		co.ClassID = ids[0]
+	default:
+		co.ClassID = ""
+	}
+}
+
+// DownloadExhibits saves every document of a filing, verbatim, under
+// dir/<accession>/. EDGAR's own index pages and the complete submission
+// (<accession>.txt) are left out, as are files that are already on disk and
+// intact. It returns the number of files and bytes written. Each file
+// streams to disk as it arrives; one over maxBytes or MaxFileBytes is left
+// out and the rest still saved, and the error then wraps ErrTooLarge. A
+// maxBytes of 0 means no limit of its own.
+func (c *Client) DownloadExhibits(ctx context.Context, f Filing, dir string, maxBytes int64) (int, int64, error) {
+	acc, _, err := ParseAccession(f.Accession)
+	if err != nil {
+		return 0, 0, err
+	}
+	f.Accession = acc
+	idx, err := c.FilingIndex(ctx, f)
+	if err != nil {
+		return 0, 0, err
+	}
+	exDir := filepath.Join(dir, f.Accession)
+	fsys := c.fs()
+	if err := fsys.MkdirAll(exDir, 0755); err != nil {
+		return 0, 0, err
+	}
+
+	count, total := 0, int64(0)
+	var tooLarge error
+	for _, doc := range idx.Directory.Item {
+		name := docBaseName(doc.Name)
+		if skipIndexItem(doc.Type, name, f.Accession) {
+			continue
+		}
+		target := filepath.Join(exDir, name)
+		if fileExists(fsys, target) {
+			if intact(fsys, dir, target) {
+				continue
+			}
+			c.Logger.Warn("checksum mismatch, downloading again", "file", target)
+		}
+		entry, err := c.saveFile(ctx, c.ArchiveURL(f.Company.CIK, f.Accession, doc.Name), target, maxBytes)
+		if errors.Is(err, ErrTooLarge) {
+			c.Logger.Warn("exhibit too large, not saved", "file", target, "err", err)
+			if tooLarge == nil {
+				tooLarge = fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+		if err != nil {
+			return count, total, fmt.Errorf("%s: %w", name, err)
+		}
+		entry.Accession = f.Accession
+		if err := recordEntry(fsys, dir, target, entry); err != nil {
+			return count, total, fmt.Errorf("updating manifest: %w", err)
+		}
+		count++
+		total += entry.Size
+	}
+	return count, total, tooLarge
+}
+
+// Sidecar is the metadata written as JSON next to each downloaded filing so
+// the corpus stays self-describing.
+type Sidecar struct {
+	Accession   string    `json:"accession"`
+	Form        string    `json:"form"`
+	FilingDate  string    `json:"filing_date"`
+	ReportDate  string    `json:"report_date,omitempty"`
+	Accepted    string    `json:"accepted,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CIK         string    `json:"cik"`
+	SeriesID    string    `json:"series_id,omitempty"`
+	ClassID     string    `json:"class_id,omitempty"`
+	Document    string    `json:"document"`
+	SourceURL   string    `json:"source_url"`
+	RetrievedAt time.Time `json:"retrieved_at"`
+
+	// SupersededBy is Filing.SupersededBy as known when the file was saved.
+	SupersededBy string `json:"superseded_by,omitempty"`
+}
+
+func newSidecar(f Filing, url string) Sidecar {
+	return Sidecar{
+		Accession:   f.Accession,
+		Form:        f.Form,
+		FilingDate:  f.FilingDate,
+		ReportDate:  f.ReportDate,
+		Accepted:    f.Accepted,
+		Description: f.Description,
+		CIK:         PadCIK(f.Company.CIK),
+		SeriesID:    f.Company.SeriesID,
+		ClassID:     f.Company.ClassID,
+		Document:    f.Document,
+		SourceURL:   url,
+		RetrievedAt: time.Now().UTC(),
+
+		SupersededBy: f.SupersededBy,
+	}
+}
+
+// sidecarPath names the sidecar after the whole file name, so the .txt and
+// the .htm that KeepHTML saves side by side each keep their own.
+func sidecarPath(filename string) string {
+	return filename + ".json"
+}
+
+// legacySidecarPath is where earlier versions put the sidecar: the file name
+// with its extension replaced.
+func legacySidecarPath(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + ".json"
+}
+
+func fileExists(fsys FS, name string) bool {
+	_, err := fsys.Stat(name)
+	return err == nil
+}
+
+// writeFiling writes the document, then its sidecar, then records the
+// document in the manifest of dir.
+func writeFiling(fsys FS, dir, filename string, content []byte, meta Sidecar) (int64, error) {
+	if err := fsys.WriteFile(filename, content, 0644); err != nil {
+		return 0, err
+	}
+	if err := writeSidecar(fsys, filename, meta); err != nil {
+		return 0, err
+	}
+	if err := recordFile(fsys, dir, filename, meta.Accession, content); err != nil {
+		return 0, fmt.Errorf("updating manifest: %w", err)
+	}
+	return int64(len(content)), nil
+}
+
+// writeSidecar writes the sidecar of filename through a temp file and rename
+// so a crash never leaves a half-written one behind.
+func writeSidecar(fsys FS, filename string, meta Sidecar) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := sidecarPath(filename) + ".tmp"
+	if err := fsys.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing sidecar: %w", err)
+	}
+	if err := fsys.Rename(tmp, sidecarPath(filename)); err != nil {
+		return fmt.Errorf("writing sidecar: %w", err)
+	}
+	return nil
+}
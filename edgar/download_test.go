@@ -0,0 +1,329 @@
+package edgar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serveFiling answers index.json, the SGML headers and any document of one
+// filing; every other document is a small HTML page naming its file.
+func serveFiling(index, headers string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]; {
+		case name == "index.json":
+			w.Write([]byte(index))
+		case strings.HasSuffix(name, "-index-headers.html"):
+			if headers == "" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(headers))
+		default:
+			w.Write([]byte("<html><body><p>document " + name + "</p></body></html>"))
+		}
+	})
+}
+
+func indexJSON(items ...string) string {
+	var b strings.Builder
+	b.WriteString(`{"directory":{"item":[`)
+	for i, it := range items {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		name, typ, _ := strings.Cut(it, ":")
+		b.WriteString(`{"name":"` + name + `","type":"` + typ + `","size":"100"}`)
+	}
+	b.WriteString(`]}}`)
+	return b.String()
+}
+
+func TestPrimaryDocument(t *testing.T) {
+	const acc = "0000320193-24-000123"
+	headers := func(docs ...string) string {
+		var b strings.Builder
+		for _, d := range docs {
+			typ, name, _ := strings.Cut(d, ":")
+			b.WriteString("&lt;DOCUMENT&gt;\n&lt;TYPE&gt;" + typ + "\n&lt;SEQUENCE&gt;1\n&lt;FILENAME&gt;" + name + "\n")
+		}
+		return "<html><body><pre>" + b.String() + "</pre></body></html>"
+	}
+	tests := []struct {
+		name, index, headers string
+		want                 string // empty for an error
+	}{
+		{"single document, index pages and folders skipped",
+			indexJSON("0000320193-24-000123-index.html:text.gif", "0000320193-24-000123-index-headers.html:text.gif",
+				"R:folder.gif", "0000320193-24-000123.txt:text.gif", "aapl-20240928.htm:text.gif", "logo.jpg:image2.gif"),
+			"", "aapl-20240928.htm"},
+		{"the document typed as the form wins",
+			indexJSON("ex21.htm:text.gif", "aapl-20240928.htm:text.gif"),
+			headers("EX-21:ex21.htm", "10-K:aapl-20240928.htm"), "aapl-20240928.htm"},
+		{"exhibits are passed over",
+			indexJSON("ex99.htm:text.gif", "cover.htm:text.gif"),
+			headers("EX-99.1:ex99.htm", "COVER:cover.htm"), "cover.htm"},
+		{"only exhibits",
+			indexJSON("ex21.htm:text.gif", "ex31.htm:text.gif", "0000320193-24-000123-index.htm:text.gif"),
+			headers("EX-21:ex21.htm", "EX-31.1:ex31.htm"), ""},
+		{"nothing but index pages",
+			indexJSON("0000320193-24-000123-index.html:text.gif", "Financial_Report.xlsx:text.gif", "R:folder.gif"),
+			"", ""},
+	}
+	for _, tt := range tests {
+		c := newTestClient(t, serveFiling(tt.index, tt.headers))
+		got, err := c.primaryDocument(context.Background(), Filing{Company: Company{CIK: 320193}, Form: "10-K", Accession: acc})
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("%s: got %q, want an error", tt.name, got)
+		case tt.want != "" && (err != nil || got != tt.want):
+			t.Errorf("%s: got %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+// TestRenderEmptyPrimaryDocument checks that a filing listed without a
+// primaryDocument is fetched through its index.
+func TestRenderEmptyPrimaryDocument(t *testing.T) {
+	c := newTestClient(t, serveFiling(indexJSON("aapl-20240928.htm:text.gif", "R1.htm:folder.gif"), ""))
+	f := Filing{Company: Company{CIK: 320193, Ticker: "AAPL"}, Form: "10-K", Accession: "0000320193-24-000123", FilingDate: "2024-11-01"}
+	text, err := c.Render(context.Background(), f, DownloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(text), "document aapl-20240928.htm") {
+		t.Errorf("rendered the wrong document:\n%s", text)
+	}
+}
+
+func TestFilingPathForms(t *testing.T) {
+	dir := filepath.Join("out", "filings_AAPL")
+	base := Filing{FilingDate: "2024-11-01", ReportDate: "2024-09-28", Accession: "0000320193-24-000123"}
+	tests := []struct{ form, want string }{
+		{"10-K", "2024-11-01_10-K_2024-09-28_0000320193-24-000123.txt"},
+		{"10-K/A", "2024-11-01_10-K-A_2024-09-28_0000320193-24-000123.txt"},
+		{"SC 13G/A", "2024-11-01_SC 13G-A_2024-09-28_0000320193-24-000123.txt"},
+		{" S-1/A ", "2024-11-01_S-1-A_2024-09-28_0000320193-24-000123.txt"},
+		{`X:Y\Z*?"<>|`, "2024-11-01_X-Y-Z------_2024-09-28_0000320193-24-000123.txt"},
+	}
+	for _, tt := range tests {
+		f := base
+		f.Form = tt.form
+		got := FilingPath(dir, f, "text")
+		if filepath.Dir(got) != dir || filepath.Base(got) != tt.want {
+			t.Errorf("FilingPath for %q = %s, want %s", tt.form, got, filepath.Join(dir, tt.want))
+		}
+	}
+
+	// The forms EDGAR uses, amendments included, keep distinct file names
+	// for the same filing date, period and accession.
+	forms := []string{"8-K", "8-K12B", "10-K405", "SC 13G", "SC 13D", "DEF 14A", "DEFA14A", "S-1", "S-3", "S-4", "424B2"}
+	forms = append(forms, CompanyForms...)
+	forms = append(forms, ForeignForms...)
+	forms = append(forms, FundForms...)
+	seen := make(map[string]string)
+	for _, form := range forms {
+		for _, variant := range []string{form, form + "/A"} {
+			f := base
+			f.Form = variant
+			path := FilingPath(dir, f, "text")
+			if prev, ok := seen[path]; ok {
+				t.Errorf("%q and %q both map to %s", prev, variant, path)
+			}
+			seen[path] = variant
+		}
+	}
+}
+
+// TestKeepHTMLSidecars checks that the text and the HTML KeepHTML saves
+// next to it each get their own sidecar, and that a file with the sidecar
+// name earlier versions used still counts as saved.
+func TestKeepHTMLSidecars(t *testing.T) {
+	c := newTestClient(t, serveFiling(indexJSON("aapl-20240928.htm:text.gif"), ""))
+	mem := newMemFS()
+	c.FS = mem
+	dir := filepath.Join(t.TempDir(), "filings_AAPL")
+	mem.MkdirAll(dir, 0755)
+	f := Filing{Company: Company{CIK: 320193, Ticker: "AAPL"}, Form: "10-K", Accession: "0000320193-24-000123",
+		Document: "aapl-20240928.htm", FilingDate: "2024-11-01", ReportDate: "2024-09-28"}
+	opts := DownloadOptions{KeepHTML: true}
+	ctx := context.Background()
+
+	if _, err := c.Download(ctx, f, dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{opts.Path(dir, f), opts.htmlPath(dir, f)} {
+		data, err := mem.ReadFile(name + ".json")
+		var meta Sidecar
+		if err != nil || json.Unmarshal(data, &meta) != nil || meta.Accession != f.Accession {
+			t.Errorf("sidecar of %s: %q, %v", filepath.Base(name), data, err)
+		}
+	}
+	if _, err := mem.Stat(legacySidecarPath(opts.Path(dir, f))); err == nil {
+		t.Error("the shared legacy sidecar was written too")
+	}
+	if _, err := c.Download(ctx, f, dir, opts); !errors.Is(err, ErrFileExists) {
+		t.Errorf("second download: err = %v, want ErrFileExists", err)
+	}
+
+	// Saved by an earlier version: X.txt with its sidecar at X.json.
+	old := newMemFS()
+	c.FS = old
+	name := DownloadOptions{}.Path(dir, f)
+	old.WriteFile(name, []byte("text"), 0644)
+	old.WriteFile(legacySidecarPath(name), []byte(`{"accession":"`+f.Accession+`"}`), 0644)
+	if _, err := c.Download(ctx, f, dir, DownloadOptions{}); !errors.Is(err, ErrFileExists) {
+		t.Errorf("download over a legacy sidecar: err = %v, want ErrFileExists", err)
+	}
+}
+
+// fundHeaders is the escaped SGML header of a fund filing covering series.
+func fundHeaders(series ...string) string {
+	var b strings.Builder
+	b.WriteString("<html><body><pre>&lt;SERIES-AND-CLASSES-CONTRACTS-DATA&gt;\n")
+	for _, s := range series {
+		id, classes, _ := strings.Cut(s, ":")
+		b.WriteString("&lt;SERIES&gt;\n&lt;OWNER-CIK&gt;0000036405\n&lt;SERIES-ID&gt;" + id + "\n")
+		for _, class := range strings.Split(classes, ",") {
+			b.WriteString("&lt;CLASS-CONTRACT&gt;\n&lt;CLASS-CONTRACT-ID&gt;" + class + "\n&lt;/CLASS-CONTRACT&gt;\n")
+		}
+		b.WriteString("&lt;/SERIES&gt;\n")
+	}
+	return b.String() + "</pre></body></html>"
+}
+
+// TestFundSeries checks which series and class the sidecar of a fund
+// filing gets, from its header or the ticker listing's.
+func TestFundSeries(t *testing.T) {
+	tests := []struct {
+		name             string
+		headers          string
+		series, class    string // from company_tickers_mf.json
+		wantSer, wantCls string
+	}{
+		{"one series from the header", fundHeaders("S000002277:C000005944"), "", "", "S000002277", "C000005944"},
+		{"the header overrides the listing", fundHeaders("S000002277:C000005944"), "S000009999", "C000009999", "S000002277", "C000005944"},
+		{"the listing's series among several", fundHeaders("S000001111:C000001111", "S000002277:C000005944,C000092053"),
+			"S000002277", "C000092053", "S000002277", "C000092053"},
+		{"several classes, none known", fundHeaders("S000002277:C000005944,C000092053"), "", "", "S000002277", ""},
+		{"several series, none known", fundHeaders("S000001111:C000001111", "S000002277:C000005944"), "S000009999", "C000009999", "", ""},
+		{"no header, the listing stays", "", "S000002277", "C000005944", "S000002277", "C000005944"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, serveFiling(indexJSON("fund.htm:text.gif"), tt.headers))
+			mem := newMemFS()
+			c.FS = mem
+			dir := filepath.Join(t.TempDir(), "filings_VFINX")
+			mem.MkdirAll(dir, 0755)
+			f := Filing{Company: Company{CIK: 36405, Ticker: "VFINX", SeriesID: tt.series, ClassID: tt.class},
+				Form: "N-CSR", Accession: "0000932471-24-004528", Document: "fund.htm", FilingDate: "2024-03-01"}
+			if _, err := c.Download(context.Background(), f, dir, DownloadOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			data, err := mem.ReadFile(sidecarPath(DownloadOptions{}.Path(dir, f)))
+			var meta Sidecar
+			if err != nil || json.Unmarshal(data, &meta) != nil {
+				t.Fatalf("sidecar: %q, %v", data, err)
+			}
+			if meta.SeriesID != tt.wantSer || meta.ClassID != tt.wantCls {
+				t.Errorf("series %q class %q, want %q and %q", meta.SeriesID, meta.ClassID, tt.wantSer, tt.wantCls)
+			}
+		})
+	}
+}
+
+// TestDownloadExhibitsLimits checks that exhibits honor the per-call byte
+// limit, that an error status saves nothing, and that a canceled context
+// stops the download.
+func TestDownloadExhibitsLimits(t *testing.T) {
+	big := strings.Repeat("<p>exhibit</p>", 1000)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]; name {
+		case "index.json":
+			w.Write([]byte(indexJSON("form10k.htm:text.gif", "ex-99.htm:text.gif", "big.htm:text.gif",
+				"0000320193-24-000123.txt:text.gif", "0000320193-24-000123-index.htm:text.gif", "R:folder.gif")))
+		case "big.htm":
+			w.Write([]byte("<html><body>" + big + "</body></html>"))
+		default:
+			w.Write([]byte("<html><body><p>document " + name + "</p></body></html>"))
+		}
+	}))
+	mem := newMemFS()
+	c.FS = mem
+	dir := filepath.Join(t.TempDir(), "filings_AAPL")
+	f := Filing{Company: Company{CIK: 320193}, Form: "10-K", Accession: "0000320193-24-000123", FilingDate: "2024-11-01"}
+	exDir := filepath.Join(dir, f.Accession)
+
+	n, _, err := c.DownloadExhibits(context.Background(), f, dir, 5000)
+	if !errors.Is(err, ErrTooLarge) || !strings.Contains(err.Error(), "big.htm") {
+		t.Errorf("err = %v, want ErrTooLarge for big.htm", err)
+	}
+	if n != 2 {
+		t.Errorf("%d files saved, want 2", n)
+	}
+	for _, name := range []string{"big.htm", "big.htm.part", "0000320193-24-000123.txt", "0000320193-24-000123-index.htm"} {
+		if _, err := mem.Stat(filepath.Join(exDir, name)); err == nil {
+			t.Errorf("%s is on disk", name)
+		}
+	}
+	if n, _, err := c.DownloadExhibits(context.Background(), f, dir, 0); err != nil || n != 1 {
+		t.Errorf("without a limit: %d new files, %v; want big.htm alone", n, err)
+	}
+
+	f.Accession = "0000320193-24-000124"
+	c2 := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/index.json") {
+			w.Write([]byte(indexJSON("gone.htm:text.gif")))
+			return
+		}
+		http.Error(w, "<html><body>not here</body></html>", http.StatusNotFound)
+	}))
+	c2.FS = mem
+	if _, _, err := c2.DownloadExhibits(context.Background(), f, dir, 0); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("err = %v, want status 404", err)
+	}
+	if _, err := mem.Stat(filepath.Join(dir, f.Accession, "gone.htm")); err == nil {
+		t.Error("the 404 page was saved")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := c.DownloadExhibits(ctx, f, dir, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled: err = %v, want context.Canceled", err)
+	}
+}
+
+// TestNestedDocumentNames checks that a document listed under a subpath is
+// fetched by its full path but saved, and found again, by its base name.
+func TestNestedDocumentNames(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/index.json"):
+			w.Write([]byte(indexJSON("sub/ex-1.htm:text.gif")))
+		case strings.HasSuffix(r.URL.Path, "/000032019324000123/sub/ex-1.htm"):
+			w.Write([]byte("<html><body><p>nested exhibit</p></body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	mem := newMemFS()
+	c.FS = mem
+	dir := filepath.Join(t.TempDir(), "filings_AAPL")
+	f := Filing{Company: Company{CIK: 320193}, Form: "10-K", Accession: "0000320193-24-000123", FilingDate: "2024-11-01"}
+
+	if n, _, err := c.DownloadExhibits(context.Background(), f, dir, 0); err != nil || n != 1 {
+		t.Fatalf("DownloadExhibits: %d files, %v; want 1", n, err)
+	}
+	if _, err := mem.Stat(filepath.Join(dir, f.Accession, "ex-1.htm")); err != nil {
+		t.Errorf("nested exhibit not saved by its base name: %v", err)
+	}
+	f.Document = "sub/ex-1.htm"
+	if data, err := readSaved(mem, dir, f, DownloadOptions{}); err != nil || !strings.Contains(string(data), "nested exhibit") {
+		t.Errorf("readSaved(%s) = %q, %v", f.Document, data, err)
+	}
+}
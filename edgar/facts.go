@@ -0,0 +1,145 @@
+package edgar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CompanyFacts mirrors data.sec.gov/api/xbrl/companyfacts: every XBRL fact a
+// company has reported, keyed by taxonomy ("us-gaap", "dei", …) and concept.
+type CompanyFacts struct {
+	CIK        int                                `json:"cik"`
+	EntityName string                             `json:"entityName"`
+	Facts      map[string]map[string]ConceptFacts `json:"facts"`
+}
+
+// ConceptFacts holds the values of one concept per unit (e.g. "USD").
+type ConceptFacts struct {
+	Label       string            `json:"label"`
+	Description string            `json:"description"`
+	Units       map[string][]Fact `json:"units"`
+}
+
+// Fact is a single reported value. Start is empty for point-in-time facts.
+type Fact struct {
+	Start string  `json:"start,omitempty"`
+	End   string  `json:"end"`
+	Value float64 `json:"val"`
+	Accn  string  `json:"accn"`
+	FY    int     `json:"fy"`
+	FP    string  `json:"fp"`
+	Form  string  `json:"form"`
+	Filed string  `json:"filed"`
+	Frame string  `json:"frame,omitempty"`
+}
+
+// CompanyFacts fetches all XBRL facts of a company.
+func (c *Client) CompanyFacts(ctx context.Context, cik int) (CompanyFacts, error) {
+	resp, err := c.get(ctx, fmt.Sprintf("%s/api/xbrl/companyfacts/CIK%s.json", c.dataURL(), PadCIK(cik)))
+	if err != nil {
+		return CompanyFacts{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return CompanyFacts{}, fmt.Errorf("no XBRL facts for CIK %s", PadCIK(cik))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return CompanyFacts{}, fmt.Errorf("company facts: status %d", resp.StatusCode)
+	}
+	var cf CompanyFacts
+	if err := json.NewDecoder(resp.Body).Decode(&cf); err != nil {
+		return CompanyFacts{}, fmt.Errorf("decoding company facts: %w", err)
+	}
+	return cf, nil
+}
+
+// Concept looks up "taxonomy:Name", e.g. "us-gaap:Revenues"; a bare name is
+// taken to be us-gaap.
+func (cf CompanyFacts) Concept(concept string) (ConceptFacts, bool) {
+	taxonomy, name, ok := strings.Cut(concept, ":")
+	if !ok {
+		taxonomy, name = "us-gaap", concept
+	}
+	f, ok := cf.Facts[taxonomy][name]
+	return f, ok
+}
+
+// SortFacts orders facts by period end, newest first, then by filing date.
+func SortFacts(facts []Fact) {
+	sort.SliceStable(facts, func(i, j int) bool {
+		if facts[i].End != facts[j].End {
+			return facts[i].End > facts[j].End
+		}
+		return facts[i].Filed > facts[j].Filed
+	})
+}
+
+// Latest returns the most recent fact of the concept across all its units:
+// the latest period end, and of those the latest filed. keep, if not nil,
+// skips the facts it rejects. ok is false when no fact is left.
+func (cf ConceptFacts) Latest(keep func(Fact) bool) (unit string, fact Fact, ok bool) {
+	for u, facts := range cf.Units {
+		for _, f := range facts {
+			if keep != nil && !keep(f) {
+				continue
+			}
+			newer := f.End > fact.End || f.End == fact.End && (f.Filed > fact.Filed || f.Filed == fact.Filed && u < unit)
+			if !ok || newer {
+				unit, fact, ok = u, f, true
+			}
+		}
+	}
+	return unit, fact, ok
+}
+
+// Frame mirrors data.sec.gov/api/xbrl/frames: one concept as reported by
+// every company for a single calendar period.
+type Frame struct {
+	Taxonomy string       `json:"taxonomy"`
+	Tag      string       `json:"tag"`
+	Period   string       `json:"ccp"`
+	Unit     string       `json:"uom"`
+	Label    string       `json:"label"`
+	Data     []FrameValue `json:"data"`
+}
+
+// FrameValue is one company's value in a Frame.
+type FrameValue struct {
+	Accn       string  `json:"accn"`
+	CIK        int     `json:"cik"`
+	EntityName string  `json:"entityName"`
+	Location   string  `json:"loc"`
+	Start      string  `json:"start,omitempty"`
+	End        string  `json:"end"`
+	Value      float64 `json:"val"`
+}
+
+// Frame fetches concept ("taxonomy:Name", bare names are us-gaap) in unit
+// for period, e.g. "CY2023" for annual durations, "CY2023Q1" for a quarter
+// or "CY2023Q4I" for instants.
+func (c *Client) Frame(ctx context.Context, concept, unit, period string) (Frame, error) {
+	taxonomy, name, ok := strings.Cut(concept, ":")
+	if !ok {
+		taxonomy, name = "us-gaap", concept
+	}
+	resp, err := c.get(ctx, fmt.Sprintf("%s/api/xbrl/frames/%s/%s/%s/%s.json", c.dataURL(), taxonomy, name, unit, period))
+	if err != nil {
+		return Frame{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return Frame{}, fmt.Errorf("no %s frame for %s in %s", period, concept, unit)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Frame{}, fmt.Errorf("frame: status %d", resp.StatusCode)
+	}
+	var fr Frame
+	if err := json.NewDecoder(resp.Body).Decode(&fr); err != nil {
+		return Frame{}, fmt.Errorf("decoding frame: %w", err)
+	}
+	return fr, nil
+}
@@ -0,0 +1,578 @@
+package edgar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Submissions struct {
+	Filings struct {
+		Recent FilingArrays `json:"recent"`
+		Files  []struct {
+			Name       string `json:"name"`
+			FilingFrom string `json:"filingFrom"`
+			FilingTo   string `json:"filingTo"`
+		} `json:"files"`
+	} `json:"filings"`
+}
+
+// FilingArrays is the column-oriented filing list used both by the "recent"
+// block and by the older submissions shards (CIK…-submissions-001.json).
+type FilingArrays struct {
+	AccessionNumber    []string `json:"accessionNumber"`
+	FilingDate         []string `json:"filingDate"`
+	Form               []string `json:"form"`
+	PrimaryDoc         []string `json:"primaryDocument"`
+	ReportDate         []string `json:"reportDate"`
+	AcceptanceDateTime []string `json:"acceptanceDateTime"`
+	PrimaryDocDesc     []string `json:"primaryDocDescription"`
+	Size               []int64  `json:"size"`
+	IsXBRL             []int    `json:"isXBRL"`
+}
+
+// merge appends b, first padding every column to len(Form) so a short
+// column never shifts the rows that follow it.
+func (a *FilingArrays) merge(b FilingArrays) {
+	n := len(a.Form)
+	a.AccessionNumber = append(pad(a.AccessionNumber, n), b.AccessionNumber...)
+	a.FilingDate = append(pad(a.FilingDate, n), b.FilingDate...)
+	a.PrimaryDoc = append(pad(a.PrimaryDoc, n), b.PrimaryDoc...)
+	a.ReportDate = append(pad(a.ReportDate, n), b.ReportDate...)
+	a.AcceptanceDateTime = append(pad(a.AcceptanceDateTime, n), b.AcceptanceDateTime...)
+	a.PrimaryDocDesc = append(pad(a.PrimaryDocDesc, n), b.PrimaryDocDesc...)
+	a.Size = append(pad(a.Size, n), b.Size...)
+	a.IsXBRL = append(pad(a.IsXBRL, n), b.IsXBRL...)
+	a.Form = append(a.Form, b.Form...)
+}
+
+func pad[T any](col []T, n int) []T {
+	var zero T
+	for len(col) < n {
+		col = append(col, zero)
+	}
+	return col[:n]
+}
+
+// at tolerates the recent arrays being shorter than Form, which EDGAR
+// occasionally serves for thinly-filed companies.
+func at[T any](s []T, i int) T {
+	if i < len(s) {
+		return s[i]
+	}
+	var zero T
+	return zero
+}
+
+// CompanyForms are fetched by default. Foreign private issuers file 20-F (or
+// 40-F from Canada) instead of 10-K and 6-K instead of 10-Q, and funds file
+// FundForms under the trust's CIK; those are only fetched when asked for, by
+// Forms or the CLI's form groups. Download recognizes FundForms and records
+// the fund series and class they cover.
+var (
+	CompanyForms = []string{"10-K", "10-Q"}
+	ForeignForms = []string{"20-F", "40-F", "6-K"}
+	FundForms    = []string{"N-CSR", "N-CSRS", "NPORT-P"}
+)
+
+// FetchOptions selects which filings FetchFilings returns. The zero value
+// means the default forms, any date and no limit.
+type FetchOptions struct {
+	// Forms lists the form types to keep; empty means CompanyForms.
+	// Matching ignores case.
+	Forms []string
+	// From and To bound the filing date; either may be zero.
+	From, To time.Time
+	// Limit caps the number of filings returned; 0 means all.
+	Limit int
+	// IncludeAmendments also matches the /A variants of Forms.
+	IncludeAmendments bool
+	// PreferAmendment also matches the /A variants of Forms, keeping only
+	// the latest version per form and report period.
+	PreferAmendment bool
+	// Years keeps filings whose report date (fiscal period end) falls in one
+	// of these years; filings without a report date are dropped. Empty means
+	// any year.
+	Years []int
+	// OldestFirst returns filings in chronological order, so Limit keeps the
+	// earliest ones. Every older submissions shard in range is loaded.
+	OldestFirst bool
+	// DescContains keeps filings whose primary document description
+	// (primaryDocDescription, e.g. "ANNUAL REPORT") contains this text,
+	// ignoring case. Empty means any description.
+	DescContains string
+}
+
+// order sorts filings newest first, or oldest first with OldestFirst.
+func (o FetchOptions) order(filings []Filing) {
+	SortFilings(filings)
+	if o.OldestFirst {
+		slices.Reverse(filings)
+	}
+}
+
+// IsAmendment reports whether form is an amendment such as "10-K/A" or
+// "10-K/A (Amendment No. 2)".
+func IsAmendment(form string) bool {
+	_, suffix, ok := strings.Cut(strings.ToUpper(form), "/A")
+	return ok && (suffix == "" || strings.HasPrefix(suffix, " "))
+}
+
+// BaseForm strips an amendment suffix: "10-K/A (Amendment No. 2)" → "10-K".
+func BaseForm(form string) string {
+	form = strings.TrimSpace(form)
+	if !IsAmendment(form) {
+		return form
+	}
+	i := strings.Index(strings.ToUpper(form), "/A")
+	return strings.TrimSpace(form[:i])
+}
+
+// wantForm reports whether a filing passes the form filter.
+func (o FetchOptions) wantForm(form string) bool {
+	form = strings.ToUpper(strings.TrimSpace(form))
+	match := func(f string) bool {
+		if len(o.Forms) > 0 {
+			return containsFold(o.Forms, f)
+		}
+		return containsFold(CompanyForms, f)
+	}
+	return match(form) || ((o.IncludeAmendments || o.PreferAmendment) && IsAmendment(form) && match(BaseForm(form)))
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// inYears applies Years to a report date.
+func (o FetchOptions) inYears(reportDate string) bool {
+	if len(o.Years) == 0 {
+		return true
+	}
+	if len(reportDate) < 4 {
+		return false
+	}
+	y, err := strconv.Atoi(reportDate[:4])
+	return err == nil && slices.Contains(o.Years, y)
+}
+
+// inDesc applies DescContains to a primary document description.
+func (o FetchOptions) inDesc(desc string) bool {
+	return o.DescContains == "" || strings.Contains(strings.ToLower(desc), strings.ToLower(o.DescContains))
+}
+
+// inRange applies From/To to a filing date; either bound may be open.
+func (o FetchOptions) inRange(date string) bool {
+	if o.From.IsZero() && o.To.IsZero() {
+		return true
+	}
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return false
+	}
+	return !t.Before(o.From) && (o.To.IsZero() || !t.After(o.To))
+}
+
+// Filing is one document listed in a company's submissions.
+type Filing struct {
+	Company    Company `json:"-"`
+	Form       string  `json:"form"`
+	Accession  string  `json:"accession"`
+	Document   string  `json:"document"`
+	FilingDate string  `json:"filing_date"`
+	ReportDate string  `json:"report_date,omitempty"`
+
+	// Accepted is EDGAR's acceptanceDateTime, e.g. 2024-11-01T06:01:36.000Z.
+	Accepted    string `json:"accepted,omitempty"`
+	Description string `json:"description,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	IsXBRL      bool   `json:"is_xbrl,omitempty"`
+
+	// SupersededBy is the accession of the newest amendment filed later for
+	// the same form family and report period (a 10-K/A for a 10-K), whose
+	// figures may replace this filing's. Set by FetchFilings and
+	// FilingByAccession from the submissions metadata.
+	SupersededBy string `json:"superseded_by,omitempty"`
+}
+
+var reAccession = regexp.MustCompile(`^(\d{10})-?(\d{2})-?(\d{6})$`)
+
+// ParseAccession checks that s is an accession number, 18 digits either
+// dashed as 0000320193-24-000123 or bare, and returns both forms.
+func ParseAccession(s string) (dashed, plain string, err error) {
+	m := reAccession.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", "", fmt.Errorf("malformed accession number %q", s)
+	}
+	return m[1] + "-" + m[2] + "-" + m[3], m[1] + m[2] + m[3], nil
+}
+
+// FetchFilings lists a company's filings, newest first unless
+// opts.OldestFirst, filtered by opts.
+// Older submissions shards are only loaded while the recent block cannot
+// satisfy Limit or reach back to From.
+func (c *Client) FetchFilings(ctx context.Context, cik int, opts FetchOptions) ([]Filing, error) {
+	s, err := c.Submissions(ctx, cik, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var filings []Filing
+	recent := s.Filings.Recent
+	amendments := latestAmendments(recent)
+	for i, form := range recent.Form {
+		if !opts.wantForm(form) || !opts.inRange(at(recent.FilingDate, i)) || !opts.inYears(at(recent.ReportDate, i)) ||
+			!opts.inDesc(at(recent.PrimaryDocDesc, i)) {
+			continue
+		}
+		f := Filing{
+			Company:    Company{CIK: cik},
+			Form:       form,
+			Accession:  at(recent.AccessionNumber, i),
+			Document:   at(recent.PrimaryDoc, i),
+			FilingDate: at(recent.FilingDate, i),
+			ReportDate: at(recent.ReportDate, i),
+
+			Accepted:    at(recent.AcceptanceDateTime, i),
+			Description: at(recent.PrimaryDocDesc, i),
+			Size:        at(recent.Size, i),
+			IsXBRL:      at(recent.IsXBRL, i) == 1,
+		}
+		acc, _, err := ParseAccession(f.Accession)
+		if err != nil {
+			c.Logger.Warn("skipping filing", "cik", PadCIK(cik), "form", form, "index", i, "err", err)
+			continue
+		}
+		f.Accession = acc
+		f.SupersededBy = supersededBy(f, amendments)
+		filings = append(filings, f)
+	}
+	opts.order(filings)
+	// Amendments replace their originals before Limit counts them.
+	if opts.PreferAmendment {
+		filings = preferAmendments(filings)
+	}
+	if opts.Limit > 0 && len(filings) > opts.Limit {
+		filings = filings[:opts.Limit]
+	}
+	return filings, nil
+}
+
+// FilingByAccession looks up one filing of a company by accession number,
+// whatever its age and without FetchFilings' filters or limits. The metadata
+// comes from the recent submissions or, for older filings, from the filing's
+// SGML headers; Document may be empty, in which case Download finds it.
+func (c *Client) FilingByAccession(ctx context.Context, cik int, accession string) (Filing, error) {
+	acc, _, err := ParseAccession(accession)
+	if err != nil {
+		return Filing{}, err
+	}
+	s, err := c.recentSubmissions(ctx, cik)
+	if err != nil {
+		return Filing{}, err
+	}
+	recent := s.Filings.Recent
+	for i, a := range recent.AccessionNumber {
+		if a != acc {
+			continue
+		}
+		f := Filing{
+			Company:    Company{CIK: cik},
+			Form:       at(recent.Form, i),
+			Accession:  acc,
+			Document:   at(recent.PrimaryDoc, i),
+			FilingDate: at(recent.FilingDate, i),
+			ReportDate: at(recent.ReportDate, i),
+
+			Accepted:    at(recent.AcceptanceDateTime, i),
+			Description: at(recent.PrimaryDocDesc, i),
+			Size:        at(recent.Size, i),
+			IsXBRL:      at(recent.IsXBRL, i) == 1,
+		}
+		f.SupersededBy = supersededBy(f, latestAmendments(recent))
+		return f, nil
+	}
+
+	resp, err := c.get(ctx, c.ArchiveURL(cik, acc, acc+"-index-headers.html"))
+	if err != nil {
+		return Filing{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Filing{}, fmt.Errorf("filing %s of CIK %s not found (status %d)", acc, PadCIK(cik), resp.StatusCode)
+	}
+	headers, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Filing{}, fmt.Errorf("reading filing headers: %w", err)
+	}
+	return parseHeaders(html.UnescapeString(string(headers)), cik, acc)
+}
+
+var (
+	reHeaderField = regexp.MustCompile(`(CONFORMED SUBMISSION TYPE|CONFORMED PERIOD OF REPORT|FILED AS OF DATE):[ \t]*([^\r\n<]+)`)
+	reAcceptance  = regexp.MustCompile(`<ACCEPTANCE-DATETIME>\s*(\d{14})`)
+)
+
+// parseHeaders reads a Filing from the SGML header of a submission, whose
+// dates are written as YYYYMMDD.
+func parseHeaders(headers string, cik int, acc string) (Filing, error) {
+	f := Filing{Company: Company{CIK: cik}, Accession: acc}
+	dashed := func(d string) string {
+		if len(d) != 8 {
+			return d
+		}
+		return d[:4] + "-" + d[4:6] + "-" + d[6:]
+	}
+	for _, m := range reHeaderField.FindAllStringSubmatch(headers, -1) {
+		value := strings.TrimSpace(m[2])
+		switch m[1] {
+		case "CONFORMED SUBMISSION TYPE":
+			f.Form = value
+		case "CONFORMED PERIOD OF REPORT":
+			f.ReportDate = dashed(value)
+		case "FILED AS OF DATE":
+			f.FilingDate = dashed(value)
+		}
+	}
+	if m := reAcceptance.FindStringSubmatch(headers); m != nil {
+		t := m[1]
+		f.Accepted = fmt.Sprintf("%s-%s-%sT%s:%s:%s.000Z", t[:4], t[4:6], t[6:8], t[8:10], t[10:12], t[12:])
+	}
+	if f.Form == "" || f.FilingDate == "" {
+		return Filing{}, fmt.Errorf("filing %s: no form type or filing date in its headers", acc)
+	}
+	return f, nil
+}
+
+// FetchFilings calls DefaultClient.FetchFilings.
+func FetchFilings(ctx context.Context, cik int, opts FetchOptions) ([]Filing, error) {
+	return DefaultClient.FetchFilings(ctx, cik, opts)
+}
+
+// SortFilings orders filings newest first by filing date and, within a day,
+// by acceptance time, so two 8-Ks filed the same day keep their intraday
+// order. Filings without an acceptance time keep their relative order.
+func SortFilings(filings []Filing) {
+	sort.SliceStable(filings, func(i, j int) bool { return newer(filings[i], filings[j]) })
+}
+
+// newer reports whether a was filed after b. Acceptance times are ISO 8601
+// in one zone, so they compare as strings.
+func newer(a, b Filing) bool {
+	if a.FilingDate != b.FilingDate {
+		return a.FilingDate > b.FilingDate
+	}
+	return a.Accepted > b.Accepted
+}
+
+// latestAmendments maps each form family and report period ("10-K|2023-12-31")
+// to the newest amendment among the submissions.
+func latestAmendments(recent FilingArrays) map[string]Filing {
+	latest := make(map[string]Filing)
+	for i, form := range recent.Form {
+		period := at(recent.ReportDate, i)
+		if !IsAmendment(form) || period == "" {
+			continue
+		}
+		acc, _, err := ParseAccession(at(recent.AccessionNumber, i))
+		if err != nil {
+			continue
+		}
+		a := Filing{Form: form, Accession: acc, FilingDate: at(recent.FilingDate, i), Accepted: at(recent.AcceptanceDateTime, i)}
+		key := BaseForm(form) + "|" + period
+		if prev, ok := latest[key]; !ok || newer(a, prev) {
+			latest[key] = a
+		}
+	}
+	return latest
+}
+
+// supersededBy returns the accession of the amendment in amendments that
+// replaces f, if one was filed after it.
+func supersededBy(f Filing, amendments map[string]Filing) string {
+	if f.ReportDate == "" {
+		return ""
+	}
+	a, ok := amendments[BaseForm(f.Form)+"|"+f.ReportDate]
+	if !ok || a.Accession == f.Accession || !newer(a, f) {
+		return ""
+	}
+	return a.Accession
+}
+
+// preferAmendments keeps only the most recently filed document per form
+// family and report period, so a 10-K/A replaces the 10-K it amends.
+func preferAmendments(filings []Filing) []Filing {
+	latest := make(map[string]int)
+	kept := filings[:0:0]
+	for _, f := range filings {
+		if f.ReportDate == "" {
+			kept = append(kept, f)
+			continue
+		}
+		key := BaseForm(f.Form) + "|" + f.ReportDate
+		if i, ok := latest[key]; ok {
+			if newer(f, kept[i]) {
+				kept[i] = f
+			}
+			continue
+		}
+		latest[key] = len(kept)
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// Submissions returns the raw submissions of a company, with older shards
+// merged into Filings.Recent as far as opts needs them.
+func (c *Client) Submissions(ctx context.Context, cik int, opts FetchOptions) (Submissions, error) {
+	s, err := c.recentSubmissions(ctx, cik)
+	if err != nil {
+		return Submissions{}, err
+	}
+
+	// "recent" stops at ~1000 filings; older ones live in shards, newest first.
+	for _, f := range s.Filings.Files {
+		if !needOlderFilings(s.Filings.Recent, opts) {
+			break
+		}
+		if (!opts.From.IsZero() && f.FilingTo < opts.From.Format(time.DateOnly)) ||
+			(!opts.To.IsZero() && f.FilingFrom > opts.To.Format(time.DateOnly)) {
+			continue
+		}
+		shard, err := c.submissionsShard(ctx, f.Name)
+		if err != nil {
+			return s, err
+		}
+		s.Filings.Recent.merge(shard)
+	}
+	return s, nil
+}
+
+// recentSubmissions fetches the main submissions document, whose "recent"
+// block holds roughly the last 1000 filings. A CIK EDGAR does not know
+// gives ErrCIKNotFound.
+func (c *Client) recentSubmissions(ctx context.Context, cik int) (Submissions, error) {
+	resp, err := c.get(ctx, fmt.Sprintf("%s/submissions/CIK%s.json", c.dataURL(), PadCIK(cik)))
+	if err != nil {
+		return Submissions{}, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return Submissions{}, fmt.Errorf("%w: %s", ErrCIKNotFound, PadCIK(cik))
+	case resp.StatusCode != http.StatusOK:
+		return Submissions{}, fmt.Errorf("submissions of CIK %s: status %d", PadCIK(cik), resp.StatusCode)
+	}
+	var s Submissions
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return Submissions{}, fmt.Errorf("decoding submissions of CIK %s: %w", PadCIK(cik), err)
+	}
+	return s, nil
+}
+
+// FormSummary counts one form type among a company's filings.
+type FormSummary struct {
+	Form   string `json:"form"`
+	Count  int    `json:"count"`
+	Latest string `json:"latest"`
+}
+
+// RecentForms tallies every form type in the recent block of a company's
+// submissions, most frequent first, with the latest filing date of each.
+func (c *Client) RecentForms(ctx context.Context, cik int) ([]FormSummary, error) {
+	s, err := c.recentSubmissions(ctx, cik)
+	if err != nil {
+		return nil, err
+	}
+	byForm := make(map[string]*FormSummary)
+	var forms []FormSummary
+	for i, form := range s.Filings.Recent.Form {
+		sum := byForm[form]
+		if sum == nil {
+			sum = &FormSummary{Form: form}
+			byForm[form] = sum
+		}
+		sum.Count++
+		if d := at(s.Filings.Recent.FilingDate, i); d > sum.Latest {
+			sum.Latest = d
+		}
+	}
+	for _, sum := range byForm {
+		forms = append(forms, *sum)
+	}
+	sort.Slice(forms, func(i, j int) bool {
+		if forms[i].Count != forms[j].Count {
+			return forms[i].Count > forms[j].Count
+		}
+		return forms[i].Form < forms[j].Form
+	})
+	return forms, nil
+}
+
+func (c *Client) submissionsShard(ctx context.Context, name string) (FilingArrays, error) {
+	resp, err := c.get(ctx, c.dataURL()+"/submissions/"+name)
+	if err != nil {
+		return FilingArrays{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return FilingArrays{}, fmt.Errorf("submissions shard %s: status %d", name, resp.StatusCode)
+	}
+	var a FilingArrays
+	if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+		return FilingArrays{}, fmt.Errorf("decoding submissions shard %s: %w", name, err)
+	}
+	return a, nil
+}
+
+// needOlderFilings reports whether the filings loaded so far cannot yet
+// satisfy Limit or reach back to From.
+func needOlderFilings(a FilingArrays, opts FetchOptions) bool {
+	matched := 0
+	// With PreferAmendment an original and its amendments count once.
+	periods := make(map[string]bool)
+	for i, form := range a.Form {
+		if !opts.wantForm(form) || !opts.inRange(at(a.FilingDate, i)) || !opts.inYears(at(a.ReportDate, i)) ||
+			!opts.inDesc(at(a.PrimaryDocDesc, i)) {
+			continue
+		}
+		if period := at(a.ReportDate, i); opts.PreferAmendment && period != "" {
+			key := BaseForm(form) + "|" + period
+			if periods[key] {
+				continue
+			}
+			periods[key] = true
+		}
+		matched++
+	}
+	if opts.Limit > 0 && matched >= opts.Limit && !opts.OldestFirst {
+		return false
+	}
+	// A period is never reported before it starts.
+	if len(opts.Years) > 0 {
+		oldest := at(a.FilingDate, len(a.Form)-1)
+		if oldest != "" && oldest < strconv.Itoa(slices.Min(opts.Years)) {
+			return false
+		}
+	}
+	if !opts.From.IsZero() {
+		oldest := at(a.FilingDate, len(a.Form)-1)
+		return oldest == "" || oldest >= opts.From.Format(time.DateOnly)
+	}
+	return true
+}
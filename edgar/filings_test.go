@@ -0,0 +1,97 @@
+package edgar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestSubmissionsErrors(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/submissions/CIK0000000001.json":
+			http.NotFound(w, r)
+		case "/submissions/CIK0000000002.json":
+			w.Write([]byte(`{"cik":"2","filings":{"recent":{"form":["10-K"`))
+		case "/submissions/CIK0000000003.json":
+			w.WriteHeader(http.StatusTeapot)
+		}
+	}))
+	ctx := context.Background()
+	if _, err := c.FetchFilings(ctx, 1, FetchOptions{}); !errors.Is(err, ErrCIKNotFound) {
+		t.Errorf("404: err = %v, want ErrCIKNotFound", err)
+	}
+	if _, err := c.RecentForms(ctx, 2); err == nil {
+		t.Error("truncated body: no error")
+	}
+	if _, err := c.FilingByAccession(ctx, 3, "0000000003-24-000001"); err == nil || errors.Is(err, ErrCIKNotFound) {
+		t.Errorf("status 418: err = %v, want a status error", err)
+	}
+}
+
+// TestPreferAmendmentLimit checks that Limit counts filings after
+// amendments replaced their originals, loading an older shard if that
+// leaves too few.
+func TestPreferAmendmentLimit(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/submissions/CIK0000320193.json":
+			w.Write([]byte(`{"cik":"320193","filings":{"recent":{
+				"form":["10-K/A","10-K"],
+				"accessionNumber":["0000320193-25-000002","0000320193-24-000123"],
+				"filingDate":["2025-01-15","2024-11-01"],
+				"reportDate":["2024-09-28","2024-09-28"]},
+				"files":[{"name":"CIK0000320193-submissions-001.json","filingFrom":"2020-01-01","filingTo":"2023-12-31"}]}}`))
+		case "/submissions/CIK0000320193-submissions-001.json":
+			w.Write([]byte(`{"form":["10-K","10-K"],
+				"accessionNumber":["0000320193-23-000106","0000320193-22-000108"],
+				"filingDate":["2023-11-03","2022-10-28"],
+				"reportDate":["2023-09-30","2022-09-24"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	filings, err := c.FetchFilings(context.Background(), 320193, FetchOptions{Forms: []string{"10-K"}, PreferAmendment: true, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range filings {
+		got = append(got, f.Form+" "+f.ReportDate)
+	}
+	if want := []string{"10-K/A 2024-09-28", "10-K 2023-09-30"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestDefaultForms pins the filter used without Forms: 10-K and 10-Q, not
+// the 20-F, 40-F and 6-K of foreign issuers or fund reports, which must be
+// asked for.
+func TestDefaultForms(t *testing.T) {
+	tests := []struct {
+		opts FetchOptions
+		form string
+		want bool
+	}{
+		{FetchOptions{}, "10-K", true},
+		{FetchOptions{}, "10-q", true},
+		{FetchOptions{}, "10-K/A", false},
+		{FetchOptions{IncludeAmendments: true}, "10-K/A", true},
+		{FetchOptions{}, "20-F", false},
+		{FetchOptions{}, "40-F", false},
+		{FetchOptions{}, "6-K", false},
+		{FetchOptions{}, "8-K", false},
+		{FetchOptions{}, "N-CSR", false},
+		{FetchOptions{}, "NPORT-P", false},
+		{FetchOptions{Forms: FundForms}, "N-CSRS", true},
+		{FetchOptions{Forms: []string{"6-K"}}, "6-K", true},
+		{FetchOptions{Forms: []string{"6-K"}}, "10-K", false},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.wantForm(tt.form); got != tt.want {
+			t.Errorf("%+v wantForm(%q) = %v, want %v", tt.opts, tt.form, got, tt.want)
+		}
+	}
+}
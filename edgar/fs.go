@@ -0,0 +1,61 @@
+package edgar
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// File system
+// ──────────────────────────────────────────────────────────────────────────────
+//
+// Everything a Client saves or reads back (filings, sidecars, manifests,
+// exhibits, the ticker cache, the state file and the filings listed
+// offline) goes through Client.FS, so tests can run
+// Download against an in-memory file system and an httptest server (through
+// Client.HTTP) instead of the real disk and EDGAR.
+
+// FS is the subset of the os package a Client uses. Names are OS paths.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Open(name string) (io.ReadCloser, error)
+	// OpenFile opens name for writing with os.OpenFile flags.
+	OpenFile(name string, flag int, perm fs.FileMode) (io.WriteCloser, error)
+	Stat(name string) (fs.FileInfo, error)
+	// ReadDir lists a directory sorted by name, like os.ReadDir.
+	ReadDir(name string) ([]fs.DirEntry, error)
+	MkdirAll(path string, perm fs.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+}
+
+// OSFS is the FS of the operating system, used when Client.FS is nil.
+type OSFS struct{}
+
+func (OSFS) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+
+func (OSFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (OSFS) Open(name string) (io.ReadCloser, error) { return os.Open(name) }
+
+func (OSFS) OpenFile(name string, flag int, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (OSFS) Stat(name string) (fs.FileInfo, error)        { return os.Stat(name) }
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error)   { return os.ReadDir(name) }
+func (OSFS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
+func (OSFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (OSFS) Remove(name string) error                     { return os.Remove(name) }
+
+// fs returns the file system the client saves to.
+func (c *Client) fs() FS {
+	if c.FS == nil {
+		return OSFS{}
+	}
+	return c.FS
+}
@@ -0,0 +1,181 @@
+package edgar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// memFS is an in-memory FS on top of fstest.MapFS, which takes slash paths
+// without the leading slash.
+type memFS struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+func newMemFS() *memFS { return &memFS{files: fstest.MapFS{}} }
+
+func memName(name string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(name)), "/")
+}
+
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files.ReadFile(memName(name))
+}
+
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[memName(name)] = &fstest.MapFile{Data: bytes.Clone(data), Mode: perm, ModTime: time.Now()}
+	return nil
+}
+
+func (m *memFS) Open(name string) (io.ReadCloser, error) {
+	data, err := m.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// memWriter keeps what is written and stores it on Close.
+type memWriter struct {
+	m    *memFS
+	name string
+	buf  bytes.Buffer
+}
+
+func (w *memWriter) Write(p []byte) (int, error) { return w.buf.Write(p) }
+func (w *memWriter) Close() error                { return w.m.WriteFile(w.name, w.buf.Bytes(), 0644) }
+
+func (m *memFS) OpenFile(name string, flag int, perm fs.FileMode) (io.WriteCloser, error) {
+	w := &memWriter{m: m, name: name}
+	if flag&os.O_APPEND != 0 {
+		if data, err := m.ReadFile(name); err == nil {
+			w.buf.Write(data)
+		}
+	}
+	if flag&os.O_TRUNC == 0 || flag&os.O_APPEND != 0 {
+		return w, nil
+	}
+	return w, m.WriteFile(name, nil, perm)
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files.Stat(memName(name))
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files.ReadDir(memName(name))
+}
+
+func (m *memFS) MkdirAll(path string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[memName(path)]; !ok {
+		m.files[memName(path)] = &fstest.MapFile{Mode: fs.ModeDir | perm}
+	}
+	return nil
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[memName(oldpath)]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldpath, Err: fs.ErrNotExist}
+	}
+	delete(m.files, memName(oldpath))
+	m.files[memName(newpath)] = f
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[memName(name)]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, memName(name))
+	return nil
+}
+
+// TestMemFS runs a download, the state file, the manifest and an offline
+// re-conversion entirely against memory, so none of it may touch the disk.
+func TestMemFS(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/aapl-20240928.htm") {
+			w.Write([]byte("<html><body><p>Item 7. Management's Discussion</p></body></html>"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	mem := newMemFS()
+	c.FS = mem
+	root := filepath.Join(t.TempDir(), "mem")
+	dir := filepath.Join(root, "filings_AAPL")
+	ctx := context.Background()
+
+	state, err := c.OpenState(filepath.Join(root, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Filing{Company: Company{CIK: 320193, Ticker: "AAPL"}, Form: "10-K", Accession: "0000320193-24-000123",
+		Document: "aapl-20240928.htm", FilingDate: "2024-11-01", ReportDate: "2024-09-28"}
+	opts := DownloadOptions{KeepHTML: true, State: state}
+	if err := mem.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Download(ctx, f, dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	text, err := mem.ReadFile(opts.Path(dir, f))
+	if err != nil || !strings.Contains(string(text), "Management's Discussion") {
+		t.Fatalf("text in memory: %q, %v", text, err)
+	}
+	if _, err := os.Stat(root); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("the real disk was written: %v", err)
+	}
+
+	m, err := c.ReadManifest(dir)
+	if err != nil || len(m) != 2 {
+		t.Errorf("manifest = %v, %v; want the text and the html", m, err)
+	}
+	reopened, err := c.OpenState(filepath.Join(root, "state.json"))
+	if err != nil || reopened.Len() != 1 {
+		t.Errorf("reopened state: %d entries, %v", reopened.Len(), err)
+	}
+	if _, err := c.Download(ctx, f, filepath.Join(root, "elsewhere"), DownloadOptions{State: reopened}); !errors.Is(err, ErrFileExists) {
+		t.Errorf("download recorded in the state file: err = %v, want ErrFileExists", err)
+	}
+
+	c.Offline = true
+	saved, err := c.LocalFilings(dir, FetchOptions{})
+	if err != nil || len(saved) != 1 || saved[0].Accession != f.Accession {
+		t.Fatalf("LocalFilings = %+v, %v", saved, err)
+	}
+	saved[0].Company.Ticker = "AAPL"
+	pdf := DownloadOptions{Format: "pdf"}
+	if _, err := c.Download(ctx, saved[0], dir, pdf); err != nil {
+		t.Fatalf("offline pdf from the saved html: %v", err)
+	}
+	if _, err := mem.Stat(pdf.Path(dir, saved[0])); err != nil {
+		t.Error(err)
+	}
+}
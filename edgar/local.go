@@ -0,0 +1,111 @@
+package edgar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LocalFilings is Client.LocalFilings on DefaultClient.
+func LocalFilings(dir string, opts FetchOptions) ([]Filing, error) {
+	return DefaultClient.LocalFilings(dir, opts)
+}
+
+// LocalFilings lists the filings earlier runs saved in dir, or in its year
+// subdirectories (DownloadOptions.GroupByYear), from their JSON sidecars,
+// sorted and filtered like FetchFilings. Together with Offline it lets a
+// ticker be re-converted without the network.
+func (c *Client) LocalFilings(dir string, opts FetchOptions) ([]Filing, error) {
+	fsys := c.fs()
+	names, years, err := jsonFiles(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, year := range years {
+		nested, _, err := jsonFiles(fsys, filepath.Join(dir, year))
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, nested...)
+	}
+	seen := make(map[string]bool)
+	var filings []Filing
+	for _, name := range names {
+		data, err := fsys.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var meta Sidecar
+		// Other JSON files (e.g. _financials.json) have no accession.
+		if json.Unmarshal(data, &meta) != nil || meta.Accession == "" || seen[meta.Accession] {
+			continue
+		}
+		seen[meta.Accession] = true
+		if !opts.wantForm(meta.Form) || !opts.inRange(meta.FilingDate) || !opts.inYears(meta.ReportDate) ||
+			!opts.inDesc(meta.Description) {
+			continue
+		}
+		cik, _ := strconv.Atoi(meta.CIK)
+		filings = append(filings, Filing{
+			Company:    Company{CIK: cik, SeriesID: meta.SeriesID, ClassID: meta.ClassID},
+			Form:       meta.Form,
+			Accession:  meta.Accession,
+			Document:   meta.Document,
+			FilingDate: meta.FilingDate,
+			ReportDate: meta.ReportDate,
+
+			Accepted:     meta.Accepted,
+			Description:  meta.Description,
+			SupersededBy: meta.SupersededBy,
+		})
+	}
+	opts.order(filings)
+	// Amendments replace their originals before Limit counts them.
+	if opts.PreferAmendment {
+		filings = preferAmendments(filings)
+	}
+	if opts.Limit > 0 && len(filings) > opts.Limit {
+		filings = filings[:opts.Limit]
+	}
+	return filings, nil
+}
+
+// jsonFiles lists the .json files in dir and the names of its year
+// subdirectories. A missing dir has neither.
+func jsonFiles(fsys FS, dir string) (names, years []string, err error) {
+	entries, err := fsys.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range entries {
+		switch {
+		case e.IsDir() && len(e.Name()) == 4 && strings.Trim(e.Name(), "0123456789") == "":
+			years = append(years, e.Name())
+		case !e.IsDir() && strings.HasSuffix(e.Name(), ".json"):
+			names = append(names, filepath.Join(dir, e.Name()))
+		}
+	}
+	return names, years, nil
+}
+
+// readSaved returns the original document of f as kept by an earlier
+// -format html run (named by opts.NameTemplate, if any, flat or grouped by
+// year) or by DownloadExhibits.
+func readSaved(fsys FS, dir string, f Filing, opts DownloadOptions) ([]byte, error) {
+	html := DownloadOptions{Format: "html", NameTemplate: opts.NameTemplate, GroupByYear: opts.GroupByYear}
+	names := []string{html.Path(dir, f), FilingPath(dir, f, "html"), FilingPath(YearDir(dir, f), f, "html")}
+	names = append(names, legacyPaths(dir, f, "html")...)
+	for _, name := range append(names, filepath.Join(dir, f.Accession, docBaseName(f.Document))) {
+		if data, err := fsys.ReadFile(name); err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no saved HTML for %s", ErrOffline, f.Accession)
+}
@@ -0,0 +1,415 @@
+package edgar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Company struct {
+	CIK    int    `json:"cik_str"`
+	Ticker string `json:"ticker"`
+	Title  string `json:"title"`
+
+	// Set for mutual funds resolved via company_tickers_mf.json. Download
+	// checks them against the series and classes each fund filing's SGML
+	// header lists.
+	SeriesID string `json:"-"`
+	ClassID  string `json:"-"`
+}
+
+type TickerMap map[string]Company
+
+// FundTickers mirrors company_tickers_mf.json, which is column-oriented:
+// "fields" names the columns and each "data" row holds one share class.
+type FundTickers struct {
+	Fields []string        `json:"fields"`
+	Data   [][]interface{} `json:"data"`
+}
+
+// AmbiguousError lists the companies whose names matched a search.
+type AmbiguousError struct {
+	Query      string
+	Candidates []Company
+}
+
+func (e *AmbiguousError) Error() string {
+	names := make([]string, 0, 5)
+	for i, c := range e.Candidates {
+		if i == cap(names) {
+			names = append(names, "…")
+			break
+		}
+		names = append(names, c.label())
+	}
+	return fmt.Sprintf("%q matches %d companies: %s", e.Query, len(e.Candidates), strings.Join(names, ", "))
+}
+
+// NotFoundError is ErrTickerNotFound with the listed tickers closest to the
+// query, in case it was a typo.
+type NotFoundError struct {
+	Query       string
+	Suggestions []Company
+}
+
+func (e *NotFoundError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("%v: %s", ErrTickerNotFound, e.Query)
+	}
+	names := make([]string, len(e.Suggestions))
+	for i, c := range e.Suggestions {
+		names[i] = c.label()
+	}
+	return fmt.Sprintf("%v: %s; did you mean %s?", ErrTickerNotFound, e.Query, strings.Join(names, ", "))
+}
+
+func (e *NotFoundError) Unwrap() error { return ErrTickerNotFound }
+
+// label names a company in error messages: its ticker, else its CIK, and
+// its title.
+func (co Company) label() string {
+	if co.Ticker == "" {
+		return fmt.Sprintf("CIK %s (%s)", PadCIK(co.CIK), co.Title)
+	}
+	return fmt.Sprintf("%s (%s)", co.Ticker, co.Title)
+}
+
+// fetchCached returns the body of a large, slow-changing SEC file, served
+// from CacheDir while younger than TickersTTL.
+func (c *Client) fetchCached(ctx context.Context, url, name string) ([]byte, error) {
+	cachePath := ""
+	if c.CacheDir != "" {
+		cachePath = filepath.Join(c.CacheDir, name)
+		if fi, err := c.fs().Stat(cachePath); err == nil && (c.Offline || !c.RefreshTickers && time.Since(fi.ModTime()) < c.TickersTTL) {
+			if data, err := c.fs().ReadFile(cachePath); err == nil {
+				return data, nil
+			}
+		}
+	}
+
+	resp, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: status %d", name, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	// A failed cache write only costs a re-download next time.
+	if cachePath != "" && c.fs().MkdirAll(filepath.Dir(cachePath), 0755) == nil {
+		tmp := cachePath + ".tmp"
+		if c.fs().WriteFile(tmp, data, 0644) == nil {
+			c.fs().Rename(tmp, cachePath)
+		}
+	}
+	return data, nil
+}
+
+// LookupTicker resolves a ticker to its company. Mutual fund share classes
+// and, as a last resort, company names are tried when no ticker matches;
+// several name matches yield an *AmbiguousError. When nothing matches, the
+// *NotFoundError (which is ErrTickerNotFound) suggests companies: those
+// EDGAR's company search finds for the query, then listed tickers a typo
+// away from it (see suggestions).
+func (c *Client) LookupTicker(ctx context.Context, ticker string) (Company, error) {
+	raw, err := c.fetchCached(ctx, c.secURL()+"/files/company_tickers.json", "company_tickers.json")
+	if err != nil {
+		return Company{}, err
+	}
+
+	var data TickerMap
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return Company{}, fmt.Errorf("decoding tickers: %w", err)
+	}
+	for _, co := range data {
+		if strings.EqualFold(co.Ticker, ticker) {
+			return co, nil
+		}
+	}
+	co, fundErr := c.lookupFund(ctx, ticker)
+	if fundErr == nil {
+		return co, nil
+	}
+	co, err = findByName(data, ticker)
+	if errors.Is(err, ErrTickerNotFound) && !errors.Is(fundErr, ErrTickerNotFound) {
+		// Surface why the fund listing could not be checked.
+		return co, fundErr
+	}
+	if errors.Is(err, ErrTickerNotFound) {
+		return co, &NotFoundError{Query: ticker, Suggestions: c.suggestions(ctx, data, ticker)}
+	}
+	return co, err
+}
+
+// TickersForCIK is the reverse of LookupTicker: every company_tickers.json
+// entry of cik, one per ticker (a company may list several share classes),
+// in the order the SEC lists them. A CIK without a listed ticker gives
+// ErrTickerNotFound.
+func (c *Client) TickersForCIK(ctx context.Context, cik int) ([]Company, error) {
+	raw, err := c.fetchCached(ctx, c.secURL()+"/files/company_tickers.json", "company_tickers.json")
+	if err != nil {
+		return nil, err
+	}
+	var data TickerMap
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("decoding tickers: %w", err)
+	}
+	keys := make([]string, 0, 2)
+	for k, co := range data {
+		if co.CIK == cik {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no ticker for CIK %s", ErrTickerNotFound, PadCIK(cik))
+	}
+	// The keys are the SEC's row numbers, primary listing first.
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(keys[i])
+		b, _ := strconv.Atoi(keys[j])
+		return a < b
+	})
+	companies := make([]Company, len(keys))
+	for i, k := range keys {
+		companies[i] = data[k]
+	}
+	return companies, nil
+}
+
+// maxSuggestions caps the "did you mean" list of a NotFoundError.
+const maxSuggestions = 5
+
+// suggestions is the "did you mean" list for a query nothing matched: the
+// companies EDGAR's company search finds, which include filers without a
+// listed ticker, then the tickers within a typo of the query. Offline, or
+// when the search fails, only the local ticker list is used.
+func (c *Client) suggestions(ctx context.Context, data TickerMap, query string) []Company {
+	var found []Company
+	if !c.Offline {
+		var err error
+		if found, err = c.companySearch(ctx, data, query); err != nil {
+			c.Logger.Warn("company search failed, suggesting from the ticker list", "query", query, "err", err)
+		}
+	}
+	for _, co := range suggestTickers(data, query) {
+		if !slices.ContainsFunc(found, func(f Company) bool { return f.CIK == co.CIK }) {
+			found = append(found, co)
+		}
+	}
+	return found[:min(len(found), maxSuggestions)]
+}
+
+// companyFeed mirrors the Atom output of browse-edgar's company search: a
+// list of matching companies or, for a single match, its filings under one
+// company-info.
+type companyFeed struct {
+	Info    companyInfo `xml:"company-info"`
+	Entries []struct {
+		Title string      `xml:"title"`
+		Info  companyInfo `xml:"content>company-info"`
+	} `xml:"entry"`
+}
+
+type companyInfo struct {
+	CIK           string `xml:"cik"`
+	Name          string `xml:"name"`
+	ConformedName string `xml:"conformed-name"`
+}
+
+// companySearch asks EDGAR's company search (browse-edgar) for companies
+// whose names start with query, with the ticker each lists, if any.
+func (c *Client) companySearch(ctx context.Context, data TickerMap, query string) ([]Company, error) {
+	params := url.Values{
+		"action":  {"getcompany"},
+		"company": {strings.TrimSpace(query)},
+		"owner":   {"include"},
+		"count":   {"10"},
+		"output":  {"atom"},
+	}
+	resp, err := c.get(ctx, c.secURL()+"/cgi-bin/browse-edgar?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("company search: status %d", resp.StatusCode)
+	}
+	var feed companyFeed
+	dec := xml.NewDecoder(io.LimitReader(resp.Body, 1<<20))
+	// The feed declares ISO-8859-1, which encoding/xml cannot read alone.
+	dec.CharsetReader = func(label string, r io.Reader) (io.Reader, error) {
+		doc, err := io.ReadAll(r)
+		return bytes.NewReader(toUTF8(doc, "text/xml; charset="+label)), err
+	}
+	if err := dec.Decode(&feed); err != nil {
+		return nil, fmt.Errorf("decoding company search: %w", err)
+	}
+
+	infos := []companyInfo{feed.Info}
+	for _, e := range feed.Entries {
+		if e.Info.Name == "" {
+			e.Info.Name = e.Title
+		}
+		infos = append(infos, e.Info)
+	}
+	tickers := make(map[int]string)
+	for _, co := range data {
+		if t, ok := tickers[co.CIK]; !ok || co.Ticker < t {
+			tickers[co.CIK] = co.Ticker
+		}
+	}
+	var found []Company
+	for _, info := range infos {
+		cik, err := strconv.Atoi(strings.TrimSpace(info.CIK))
+		if err != nil || slices.ContainsFunc(found, func(f Company) bool { return f.CIK == cik }) {
+			continue
+		}
+		name := info.Name
+		if name == "" {
+			name = info.ConformedName
+		}
+		found = append(found, Company{CIK: cik, Ticker: tickers[cik], Title: strings.TrimSpace(name)})
+	}
+	return found, nil
+}
+
+// suggestTickers returns the companies whose tickers are within one edit
+// (two for queries longer than four letters) of query, closest first. An
+// edit is an insertion, deletion, substitution or swap of adjacent letters.
+func suggestTickers(data TickerMap, query string) []Company {
+	q := strings.ToUpper(strings.TrimSpace(query))
+	limit := 1
+	if len(q) > 4 {
+		limit = 2
+	}
+	type scored struct {
+		Company
+		dist int
+	}
+	seen := make(map[int]bool)
+	var found []scored
+	for _, co := range data {
+		if seen[co.CIK] {
+			continue
+		}
+		if d := editDistance(q, strings.ToUpper(co.Ticker)); d <= limit {
+			seen[co.CIK] = true
+			found = append(found, scored{co, d})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].dist != found[j].dist {
+			return found[i].dist < found[j].dist
+		}
+		return found[i].Ticker < found[j].Ticker
+	})
+	var out []Company
+	for i := 0; i < len(found) && i < maxSuggestions; i++ {
+		out = append(out, found[i].Company)
+	}
+	return out
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// Levenshtein plus transpositions of adjacent characters, the usual typos.
+func editDistance(a, b string) int {
+	if a == b {
+		return 0
+	}
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// findByName is the last resort when no ticker matches: a case-insensitive
+// substring search over company names.
+func findByName(data TickerMap, query string) (Company, error) {
+	q := strings.ToLower(query)
+	seen := make(map[int]bool)
+	var matches []Company
+	for _, co := range data {
+		// A CIK can have several tickers; offer it only once.
+		if strings.Contains(strings.ToLower(co.Title), q) && !seen[co.CIK] {
+			seen[co.CIK] = true
+			matches = append(matches, co)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Company{}, ErrTickerNotFound
+	case 1:
+		return matches[0], nil
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Title < matches[j].Title })
+	return Company{}, &AmbiguousError{Query: query, Candidates: matches}
+}
+
+// lookupFund resolves mutual fund share-class tickers, which are not part of
+// company_tickers.json, and keeps their series/class identifiers.
+func (c *Client) lookupFund(ctx context.Context, ticker string) (Company, error) {
+	raw, err := c.fetchCached(ctx, c.secURL()+"/files/company_tickers_mf.json", "company_tickers_mf.json")
+	if err != nil {
+		return Company{}, err
+	}
+
+	var data FundTickers
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return Company{}, fmt.Errorf("decoding fund tickers: %w", err)
+	}
+	col := make(map[string]int, len(data.Fields))
+	for i, f := range data.Fields {
+		col[f] = i
+	}
+	field := func(row []interface{}, name string) interface{} {
+		if i, ok := col[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return nil
+	}
+	for _, row := range data.Data {
+		symbol, _ := field(row, "symbol").(string)
+		if !strings.EqualFold(symbol, ticker) {
+			continue
+		}
+		cik, _ := field(row, "cik").(float64)
+		series, _ := field(row, "seriesId").(string)
+		class, _ := field(row, "classId").(string)
+		return Company{CIK: int(cik), Ticker: symbol, SeriesID: series, ClassID: class}, nil
+	}
+	return Company{}, ErrTickerNotFound
+}
@@ -0,0 +1,103 @@
+package edgar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	testTickers = `{"0":{"cik_str":320193,"ticker":"AAPL","title":"Apple Inc."},` +
+		`"1":{"cik_str":1018724,"ticker":"AMZN","title":"AMAZON COM INC"},` +
+		`"2":{"cik_str":2488,"ticker":"AMD","title":"ADVANCED MICRO DEVICES INC"}}`
+	testFunds = `{"fields":["cik","seriesId","classId","symbol"],"data":[]}`
+
+	// A company search listing two filers, one of them without a ticker.
+	testCompanyFeed = `<?xml version="1.0" encoding="ISO-8859-1" ?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<entry>
+<content type="text/xml">
+<company-info>
+<cik>0000320193</cik>
+<name>Apple Inc.</name>
+</company-info>
+</content>
+<title>Apple Inc.</title>
+</entry>
+<entry>
+<content type="text/xml">
+<company-info>
+<cik>0001446847</cik>
+</company-info>
+</content>
+<title>Apple Hospitality REIT, Inc.</title>
+</entry>
+</feed>`
+)
+
+func TestSuggestions(t *testing.T) {
+	var searches atomic.Int32
+	var searchStatus atomic.Int32
+	searchStatus.Store(http.StatusOK)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/company_tickers.json":
+			w.Write([]byte(testTickers))
+		case "/files/company_tickers_mf.json":
+			w.Write([]byte(testFunds))
+		case "/cgi-bin/browse-edgar":
+			searches.Add(1)
+			if q := r.URL.Query(); q.Get("action") != "getcompany" || q.Get("company") != "AAPLE" || q.Get("output") != "atom" {
+				t.Errorf("company search query %q", r.URL.RawQuery)
+			}
+			w.WriteHeader(int(searchStatus.Load()))
+			w.Write([]byte(testCompanyFeed))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	c.CacheDir = t.TempDir()
+	ctx := context.Background()
+
+	suggest := func() []Company {
+		t.Helper()
+		_, err := c.LookupTicker(ctx, "AAPLE")
+		var nf *NotFoundError
+		if !errors.As(err, &nf) || !errors.Is(err, ErrTickerNotFound) {
+			t.Fatalf("LookupTicker(AAPLE): err = %v, want a *NotFoundError", err)
+		}
+		return nf.Suggestions
+	}
+
+	got := suggest()
+	want := []Company{
+		{CIK: 320193, Ticker: "AAPL", Title: "Apple Inc."},
+		{CIK: 1446847, Title: "Apple Hospitality REIT, Inc."},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("suggestions = %+v, want %+v", got, want)
+	}
+	err := &NotFoundError{Query: "AAPLE", Suggestions: got}
+	if msg := err.Error(); !strings.Contains(msg, "AAPL (Apple Inc.)") || !strings.Contains(msg, "CIK 0001446847 (Apple Hospitality REIT, Inc.)") {
+		t.Errorf("NotFoundError = %q", msg)
+	}
+
+	// A failed search falls back to the tickers a typo away.
+	searchStatus.Store(http.StatusNotFound)
+	if got := suggest(); len(got) != 1 || got[0].Ticker != "AAPL" {
+		t.Errorf("suggestions after a failed search = %+v, want AAPL alone", got)
+	}
+
+	// Offline, the cached ticker list is all there is.
+	n := searches.Load()
+	c.Offline = true
+	if got := suggest(); len(got) != 1 || got[0].Ticker != "AAPL" {
+		t.Errorf("offline suggestions = %+v, want AAPL alone", got)
+	}
+	if searches.Load() != n {
+		t.Error("the company search was queried offline")
+	}
+}
@@ -0,0 +1,121 @@
+package edgar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Integrity manifest
+// ──────────────────────────────────────────────────────────────────────────────
+//
+// Every file Download and DownloadExhibits write is recorded in
+// <dir>/manifest.json with its size and SHA-256, so a later run can tell an
+// intact file from one that was truncated or damaged and fetch it again.
+
+// ManifestName is the file name of the manifest inside a download directory.
+const ManifestName = "manifest.json"
+
+// ManifestEntry describes one written file; the manifest maps paths relative
+// to the download directory (with forward slashes) to entries.
+type ManifestEntry struct {
+	Accession string `json:"accession"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+}
+
+// manifestMu serialises manifest updates from concurrent downloads.
+var manifestMu sync.Mutex
+
+// ReadManifest is Client.ReadManifest on DefaultClient.
+func ReadManifest(dir string) (map[string]ManifestEntry, error) {
+	return DefaultClient.ReadManifest(dir)
+}
+
+// ReadManifest loads the manifest of dir; a missing one is empty.
+func (c *Client) ReadManifest(dir string) (map[string]ManifestEntry, error) {
+	return readManifest(c.fs(), dir)
+}
+
+func readManifest(fsys FS, dir string) (map[string]ManifestEntry, error) {
+	m := make(map[string]ManifestEntry)
+	data, err := fsys.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, json.Unmarshal(data, &m)
+}
+
+// recordFile adds the just-written file name (inside dir) to the manifest.
+func recordFile(fsys FS, dir, name, accession string, content []byte) error {
+	sum := sha256.Sum256(content)
+	return recordEntry(fsys, dir, name, ManifestEntry{Accession: accession, Size: int64(len(content)), SHA256: hex.EncodeToString(sum[:])})
+}
+
+// recordEntry adds a file whose entry is already known to the manifest.
+func recordEntry(fsys FS, dir, name string, entry ManifestEntry) error {
+	rel, err := filepath.Rel(dir, name)
+	if err != nil {
+		return err
+	}
+
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+	m, err := readManifest(fsys, dir)
+	if err != nil {
+		return err
+	}
+	m[filepath.ToSlash(rel)] = entry
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, ManifestName+".tmp")
+	if err := fsys.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return fsys.Rename(tmp, filepath.Join(dir, ManifestName))
+}
+
+// fileEntry hashes a file on disk, for files too large to hold in memory.
+func fileEntry(fsys FS, name string) (ManifestEntry, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return ManifestEntry{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// intact reports whether name still matches its manifest entry. Files the
+// manifest does not know, such as those from older runs, are trusted.
+func intact(fsys FS, dir, name string) bool {
+	rel, err := filepath.Rel(dir, name)
+	if err != nil {
+		return true
+	}
+	manifestMu.Lock()
+	m, err := readManifest(fsys, dir)
+	manifestMu.Unlock()
+	entry, ok := m[filepath.ToSlash(rel)]
+	if err != nil || !ok {
+		return true
+	}
+
+	got, err := fileEntry(fsys, name)
+	return err == nil && got.Size == entry.Size && got.SHA256 == entry.SHA256
+}
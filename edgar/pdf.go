@@ -0,0 +1,128 @@
+package edgar
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Minimal PDF writer
+// ──────────────────────────────────────────────────────────────────────────────
+//
+// Renders the converted text as a plain, monospaced US Letter document. Only
+// the built-in Courier font is used, so nothing has to be embedded and the
+// output opens in any viewer.
+
+const (
+	pdfPageW, pdfPageH = 612, 792 // US Letter, in points
+	pdfMargin          = 50
+	pdfFontSize        = 9
+	pdfLeading         = 11
+	pdfCols            = (pdfPageW - 2*pdfMargin) * 10 / (pdfFontSize * 6) // Courier glyphs are 0.6em wide
+	pdfRows            = (pdfPageH - 2*pdfMargin) / pdfLeading
+)
+
+// winAnsi maps the typographic characters common in filings to their
+// WinAnsiEncoding bytes; Latin-1 maps to itself.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// textToPDF lays text out over as many pages as it needs.
+func textToPDF(text string) []byte {
+	var lines []string
+	for _, l := range strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n") {
+		lines = append(lines, wrapLine(l, pdfCols)...)
+	}
+	var pages [][]string
+	for len(lines) > pdfRows {
+		pages = append(pages, lines[:pdfRows])
+		lines = lines[pdfRows:]
+	}
+	pages = append(pages, lines)
+
+	// Objects 1-3 are the catalog, page tree and font; each page then takes
+	// two: the page itself and its content stream.
+	var objs []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objs = append(objs,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageH-pdfMargin)
+		for _, l := range page {
+			content.WriteByte('(')
+			content.Write(pdfEscape(l))
+			content.WriteString(") '\n")
+		}
+		content.WriteString("ET")
+		objs = append(objs,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageW, pdfPageH, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return buf.Bytes()
+}
+
+// wrapLine breaks a line at spaces so no piece is wider than cols, splitting
+// words only when a single word is too long.
+func wrapLine(line string, cols int) []string {
+	var out []string
+	for utf8.RuneCountInString(line) > cols {
+		r := []rune(line)
+		cut := cols
+		for i := cols; i > cols/2; i-- {
+			if r[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		out = append(out, string(r[:cut]))
+		line = strings.TrimLeft(string(r[cut:]), " ")
+	}
+	return append(out, line)
+}
+
+// pdfEscape encodes a line for a PDF literal string in WinAnsiEncoding.
+func pdfEscape(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b = append(b, '\\', byte(r))
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			b = append(b, byte(r))
+		default:
+			if c, ok := winAnsi[r]; ok {
+				b = append(b, c)
+			} else {
+				b = append(b, '?')
+			}
+		}
+	}
+	return b
+}
@@ -0,0 +1,148 @@
+package edgar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchOptions narrows a full-text search. The zero value searches every
+// form over EDGAR's whole full-text range (2001 onwards) with no limit.
+type SearchOptions struct {
+	Forms    []string
+	From, To time.Time
+	Limit    int
+}
+
+// SearchHit is one document matched by full-text search.
+type SearchHit struct {
+	CIK          int    `json:"cik"`
+	Company      string `json:"company"`
+	Form         string `json:"form"`
+	FilingDate   string `json:"filing_date"`
+	PeriodEnding string `json:"period_ending,omitempty"`
+	Accession    string `json:"accession"`
+	Document     string `json:"document"`
+}
+
+// Filing turns a hit into something Download accepts.
+func (h SearchHit) Filing() Filing {
+	return Filing{
+		Company:    Company{CIK: h.CIK, Title: h.Company},
+		Form:       h.Form,
+		Accession:  h.Accession,
+		Document:   h.Document,
+		FilingDate: h.FilingDate,
+		ReportDate: h.PeriodEnding,
+	}
+}
+
+// searchResponse mirrors the Elasticsearch-style body of efts search-index.
+type searchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			ID     string `json:"_id"`
+			Source struct {
+				CIKs         []string `json:"ciks"`
+				DisplayNames []string `json:"display_names"`
+				Form         string   `json:"form"`
+				FileDate     string   `json:"file_date"`
+				PeriodEnding string   `json:"period_ending"`
+				ADSH         string   `json:"adsh"`
+			} `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// Search runs an EDGAR full-text search and follows its pages until Limit
+// hits are collected or the results run out. Phrases need their own quotes,
+// e.g. `"material weakness"`.
+func (c *Client) Search(ctx context.Context, query string, opts SearchOptions) ([]SearchHit, error) {
+	params := url.Values{"q": {query}}
+	if len(opts.Forms) > 0 {
+		params.Set("forms", strings.Join(opts.Forms, ","))
+	}
+	if !opts.From.IsZero() || !opts.To.IsZero() {
+		to := opts.To
+		if to.IsZero() {
+			to = time.Now()
+		}
+		params.Set("dateRange", "custom")
+		params.Set("startdt", opts.From.Format(time.DateOnly))
+		params.Set("enddt", to.Format(time.DateOnly))
+	}
+
+	var hits []SearchHit
+	for {
+		params.Set("from", strconv.Itoa(len(hits)))
+		page, total, err := c.searchPage(ctx, params)
+		if err != nil {
+			return hits, err
+		}
+		for _, h := range page {
+			if opts.Limit > 0 && len(hits) >= opts.Limit {
+				return hits, nil
+			}
+			hits = append(hits, h)
+		}
+		if len(page) == 0 || len(hits) >= total {
+			return hits, nil
+		}
+	}
+}
+
+// Search calls DefaultClient.Search.
+func Search(ctx context.Context, query string, opts SearchOptions) ([]SearchHit, error) {
+	return DefaultClient.Search(ctx, query, opts)
+}
+
+func (c *Client) searchPage(ctx context.Context, params url.Values) ([]SearchHit, int, error) {
+	resp, err := c.get(ctx, c.searchURL()+"/LATEST/search-index?"+params.Encode())
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("full-text search: status %d", resp.StatusCode)
+	}
+	var r searchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, 0, fmt.Errorf("decoding search results: %w", err)
+	}
+
+	hits := make([]SearchHit, 0, len(r.Hits.Hits))
+	for _, h := range r.Hits.Hits {
+		src := h.Source
+		// _id is "<accession>:<document>".
+		acc, doc, _ := strings.Cut(h.ID, ":")
+		if src.ADSH != "" {
+			acc = src.ADSH
+		}
+		if dashed, _, err := ParseAccession(acc); err == nil {
+			acc = dashed
+		}
+		hit := SearchHit{
+			Form:         src.Form,
+			FilingDate:   src.FileDate,
+			PeriodEnding: src.PeriodEnding,
+			Accession:    acc,
+			Document:     doc,
+		}
+		if len(src.CIKs) > 0 {
+			hit.CIK, _ = strconv.Atoi(strings.TrimLeft(src.CIKs[0], "0"))
+		}
+		if len(src.DisplayNames) > 0 {
+			hit.Company = src.DisplayNames[0]
+		}
+		hits = append(hits, hit)
+	}
+	return hits, r.Hits.Total.Value, nil
+}
@@ -0,0 +1,72 @@
+package edgar
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Item sections of periodic reports
+// ──────────────────────────────────────────────────────────────────────────────
+//
+// 10-K and 10-Q documents are organised in numbered items ("Item 1A. Risk
+// Factors", "Item 7. Management's Discussion and Analysis", …). There is no
+// markup for them, so sections are found heuristically in the converted text.
+// A header starts a line, possibly bold ("*Item 7.*") or, with KeepTables,
+// as the first cell of a table row ("| Item 7. | …").
+
+var (
+	reItemArg    = regexp.MustCompile(`(?i)^(?:item\s*)?(\d{1,2}[a-z]?)\.?$`)
+	reItemHeader = regexp.MustCompile(`(?im)^[ \t*|]*item[ \t]+(\d{1,2}[a-z]?)(\.\d+)?\b`)
+	rePartHeader = regexp.MustCompile(`(?i)\n[ \t]*part[ \t]+[ivx]+\b[^\n]*\s*$`)
+)
+
+// ParseItem normalises an item name such as "Item 7", "item1a" or "7A" to
+// its number ("7", "1A").
+func ParseItem(s string) (string, error) {
+	m := reItemArg.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", fmt.Errorf("invalid item %q, want e.g. \"7\" or \"Item 1A\"", s)
+	}
+	return strings.ToUpper(m[1]), nil
+}
+
+// ExtractSection returns one item of a converted filing, from its header up
+// to the next item header. The table of contents lists the same headers, so
+// of all candidates the one with the longest body wins.
+func ExtractSection(text, item string) (string, error) {
+	item, err := ParseItem(item)
+	if err != nil {
+		return "", err
+	}
+	var headers [][]int
+	for _, m := range reItemHeader.FindAllStringSubmatchIndex(text, -1) {
+		// 8-K items are numbered "2.02", "7.01"; they are not 10-K items.
+		if m[4] < 0 {
+			headers = append(headers, m)
+		}
+	}
+
+	best := ""
+	for i, h := range headers {
+		if !strings.EqualFold(text[h[2]:h[3]], item) {
+			continue
+		}
+		end := len(text)
+		for _, next := range headers[i+1:] {
+			if !strings.EqualFold(text[next[2]:next[3]], item) {
+				end = next[0]
+				break
+			}
+		}
+		section := strings.TrimSpace(rePartHeader.ReplaceAllString(text[h[0]:end], ""))
+		if len(section) > len(best) {
+			best = section
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("%w: Item %s", ErrSectionNotFound, item)
+	}
+	return best, nil
+}
@@ -0,0 +1,85 @@
+package edgar
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExtractSection(t *testing.T) {
+	tests := []struct {
+		fixture, item string
+		want, not     []string // text the section must and must not contain
+	}{
+		{"10-K", "1A", []string{"Item 1A. Risk Factors", "macroeconomic conditions", "component suppliers"},
+			[]string{"Business", "PART II", "Item 7"}},
+		{"10-K", "Item 7", []string{"Management's Discussion", "Total net sales decreased 3%"},
+			[]string{"interest rate risk", "Item 7A", "Financial Statements"}},
+		{"10-K", "7a", []string{"interest rate risk"}, []string{"Total net sales", "Item 8"}},
+		{"10-Q", "1A", []string{"no material changes"}, []string{"Exhibits", "Net sales"}},
+		{"10-Q", "2", []string{"Net sales for the quarter rose 6%"}, []string{"PART II", "Risk Factors"}},
+	}
+	for _, tt := range tests {
+		for _, keepTables := range []bool{false, true} {
+			text := fixtureText(t, tt.fixture, TextOptions{KeepTables: keepTables})
+			got, err := ExtractSection(text, tt.item)
+			if err != nil {
+				t.Errorf("%s item %s (tables %v): %v", tt.fixture, tt.item, keepTables, err)
+				continue
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("%s item %s (tables %v) lacks %q:\n%s", tt.fixture, tt.item, keepTables, w, got)
+				}
+			}
+			for _, n := range tt.not {
+				if strings.Contains(got, n) {
+					t.Errorf("%s item %s (tables %v) runs into %q:\n%s", tt.fixture, tt.item, keepTables, n, got)
+				}
+			}
+		}
+	}
+}
+
+// TestExtractSectionSkipsTOC checks that the table of contents, which lists
+// the same headers with page numbers, never wins over the body.
+func TestExtractSectionSkipsTOC(t *testing.T) {
+	for _, keepTables := range []bool{false, true} {
+		text := fixtureText(t, "10-K", TextOptions{KeepTables: keepTables})
+		for _, item := range []string{"1", "1A", "7", "7A", "8"} {
+			got, err := ExtractSection(text, item)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(got, "Risk Factors 5") || strings.Contains(got, "| 20") || strings.Contains(got, "TABLE OF CONTENTS") {
+				t.Errorf("item %s (tables %v) came from the table of contents:\n%s", item, keepTables, got)
+			}
+		}
+	}
+}
+
+func TestExtractSectionMissing(t *testing.T) {
+	text := fixtureText(t, "10-Q", TextOptions{})
+	for _, item := range []string{"7", "9B"} {
+		if _, err := ExtractSection(text, item); !errors.Is(err, ErrSectionNotFound) {
+			t.Errorf("10-Q item %s: err = %v, want ErrSectionNotFound", item, err)
+		}
+	}
+	if _, err := ExtractSection(text, "Item Seven"); err == nil || errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("bad item name: err = %v, want a parse error", err)
+	}
+}
+
+func fixtureText(t *testing.T, name string, opts TextOptions) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name + ".htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := htmlToText(string(data), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return text
+}
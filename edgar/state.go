@@ -0,0 +1,132 @@
+package edgar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// State file
+// ──────────────────────────────────────────────────────────────────────────────
+//
+// The skip check of Download looks only at the output directory, so moving
+// an archive around means fetching it all again. A State remembers every
+// filing that was saved, keyed by CIK, accession number, format and section,
+// wherever it was saved, and Download skips the filings it already holds in
+// the form asked for. A filing saved as text and then as PDF has two entries.
+
+// StateEntry records where, when and in which form a filing was saved.
+type StateEntry struct {
+	Path    string    `json:"path"`
+	Format  string    `json:"format"`
+	Section string    `json:"section,omitempty"`
+	Saved   time.Time `json:"saved"`
+}
+
+func stateFormat(opts DownloadOptions) string {
+	if opts.Format == "" {
+		return "text"
+	}
+	return opts.Format
+}
+
+// State is a JSON file of the filings fetched so far. It is safe for
+// concurrent use.
+type State struct {
+	fs   FS
+	path string
+
+	mu      sync.Mutex
+	entries map[string]StateEntry
+}
+
+// OpenState is Client.OpenState on DefaultClient.
+func OpenState(path string) (*State, error) {
+	return DefaultClient.OpenState(path)
+}
+
+// OpenState loads the state file at path from the client's FS; a missing
+// file is an empty state that is created on the first Record.
+func (c *Client) OpenState(path string) (*State, error) {
+	s := &State{fs: c.fs(), path: path, entries: make(map[string]StateEntry)}
+	data, err := s.fs.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("state file %s: %w", path, err)
+	}
+	for key, e := range s.entries {
+		// Earlier versions keyed an entry by CIK and accession alone.
+		if strings.Count(key, "/") == 1 {
+			delete(s.entries, key)
+			s.entries[entryKey(key, e.Format, e.Section)] = e
+		}
+	}
+	return s, nil
+}
+
+// stateKey is CIK/accession/format, with /section when opts has one.
+func stateKey(f Filing, opts DownloadOptions) string {
+	acc, _, err := ParseAccession(f.Accession)
+	if err != nil {
+		acc = f.Accession
+	}
+	return entryKey(PadCIK(f.Company.CIK)+"/"+acc, stateFormat(opts), opts.Section)
+}
+
+func entryKey(filing, format, section string) string {
+	if section == "" {
+		return filing + "/" + format
+	}
+	return filing + "/" + format + "/" + section
+}
+
+// Lookup returns the entry of f saved in the format and section of opts, if
+// it was recorded.
+func (s *State) Lookup(f Filing, opts DownloadOptions) (StateEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[stateKey(f, opts)]
+	return e, ok
+}
+
+// Len returns the number of files recorded; a filing saved in two formats
+// counts twice.
+func (s *State) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Record notes that f was saved as name with opts and writes the state file.
+func (s *State) Record(f Filing, name string, opts DownloadOptions) error {
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := stateKey(f, opts)
+	if e, ok := s.entries[key]; ok && e.Path == name {
+		return nil
+	}
+	s.entries[key] = StateEntry{Path: name, Format: stateFormat(opts), Section: opts.Section, Saved: time.Now().UTC()}
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := s.fs.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return s.fs.Rename(tmp, s.path)
+}
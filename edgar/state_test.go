@@ -0,0 +1,65 @@
+package edgar
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestStateFormats records one filing as text, as PDF and as a section of
+// text; each must keep its own entry, also after reopening the file.
+func TestStateFormats(t *testing.T) {
+	c := NewClient()
+	mem := newMemFS()
+	c.FS = mem
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	f := Filing{Company: Company{CIK: 320193}, Form: "10-K", Accession: "0000320193-24-000123", FilingDate: "2024-11-01"}
+	saves := []DownloadOptions{{}, {Format: "pdf"}, {Section: "1A"}}
+
+	state, err := c.OpenState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range saves {
+		if err := state.Record(f, opts.Path(dir, f), opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reopened, err := c.OpenState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := reopened.Len(); n != len(saves) {
+		t.Errorf("%d entries, want %d", n, len(saves))
+	}
+	for _, opts := range saves {
+		e, ok := reopened.Lookup(f, opts)
+		if !ok || e.Path != opts.Path(dir, f) || e.Format != stateFormat(opts) || e.Section != opts.Section {
+			t.Errorf("Lookup(%+v) = %+v, %v", opts, e, ok)
+		}
+	}
+	if _, ok := reopened.Lookup(f, DownloadOptions{Format: "html"}); ok {
+		t.Error("Lookup found html, which was never saved")
+	}
+}
+
+// TestStateLegacyKeys reads a state file keyed by CIK and accession alone.
+func TestStateLegacyKeys(t *testing.T) {
+	c := NewClient()
+	mem := newMemFS()
+	c.FS = mem
+	path := filepath.Join(t.TempDir(), "state.json")
+	mem.WriteFile(path, []byte(`{"0000320193/0000320193-24-000123":{"path":"/a/x.pdf","format":"pdf","saved":"2024-11-02T00:00:00Z"}}`), 0644)
+
+	state, err := c.OpenState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Filing{Company: Company{CIK: 320193}, Accession: "0000320193-24-000123"}
+	if e, ok := state.Lookup(f, DownloadOptions{Format: "pdf"}); !ok || e.Path != "/a/x.pdf" {
+		t.Errorf("Lookup pdf = %+v, %v", e, ok)
+	}
+	if _, ok := state.Lookup(f, DownloadOptions{}); ok {
+		t.Error("the pdf entry was taken for text")
+	}
+}
@@ -0,0 +1,207 @@
+package edgar
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Inline XBRL (iXBRL) financial statements
+// ──────────────────────────────────────────────────────────────────────────────
+//
+// Modern 10-K/10-Q primary documents embed tagged facts as <ix:nonFraction>
+// elements. Without the presentation linkbase we cannot reproduce the exact
+// statement layout, so facts are grouped by well-known us-gaap concepts and
+// anything else is left out.
+
+var statementConcepts = map[string][]string{
+	"income_statement": {
+		"us-gaap:Revenues",
+		"us-gaap:RevenueFromContractWithCustomerExcludingAssessedTax",
+		"us-gaap:CostOfRevenue",
+		"us-gaap:CostOfGoodsAndServicesSold",
+		"us-gaap:GrossProfit",
+		"us-gaap:ResearchAndDevelopmentExpense",
+		"us-gaap:SellingGeneralAndAdministrativeExpense",
+		"us-gaap:OperatingExpenses",
+		"us-gaap:OperatingIncomeLoss",
+		"us-gaap:IncomeTaxExpenseBenefit",
+		"us-gaap:NetIncomeLoss",
+		"us-gaap:EarningsPerShareBasic",
+		"us-gaap:EarningsPerShareDiluted",
+	},
+	"balance_sheet": {
+		"us-gaap:CashAndCashEquivalentsAtCarryingValue",
+		"us-gaap:AssetsCurrent",
+		"us-gaap:Assets",
+		"us-gaap:LiabilitiesCurrent",
+		"us-gaap:LongTermDebtNoncurrent",
+		"us-gaap:Liabilities",
+		"us-gaap:StockholdersEquity",
+		"us-gaap:LiabilitiesAndStockholdersEquity",
+	},
+	"cash_flow": {
+		"us-gaap:NetCashProvidedByUsedInOperatingActivities",
+		"us-gaap:NetCashProvidedByUsedInInvestingActivities",
+		"us-gaap:NetCashProvidedByUsedInFinancingActivities",
+		"us-gaap:PaymentsToAcquirePropertyPlantAndEquipment",
+		"us-gaap:PaymentsForRepurchaseOfCommonStock",
+		"us-gaap:PaymentsOfDividends",
+	},
+}
+
+// PeriodValue is one reported value of a concept. Start is empty for
+// point-in-time (balance sheet) facts.
+type PeriodValue struct {
+	Start string  `json:"start,omitempty"`
+	End   string  `json:"end"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+// Financials maps statement name → concept → values, newest period first.
+type Financials map[string]map[string][]PeriodValue
+
+type xbrlContext struct {
+	start, end string
+	dimension  bool
+}
+
+var (
+	reContext   = regexp.MustCompile(`(?s)<(?:\w+:)?context\b[^>]*\bid="([^"]+)"[^>]*>(.*?)</(?:\w+:)?context>`)
+	reStartDate = regexp.MustCompile(`<(?:\w+:)?startDate>\s*([^<\s]+)\s*<`)
+	reEndDate   = regexp.MustCompile(`<(?:\w+:)?endDate>\s*([^<\s]+)\s*<`)
+	reInstant   = regexp.MustCompile(`<(?:\w+:)?instant>\s*([^<\s]+)\s*<`)
+	reSegment   = regexp.MustCompile(`<(?:\w+:)?(?:segment|scenario)\b`)
+	reNonFrac   = regexp.MustCompile(`(?s)<ix:nonFraction\b([^>]*)>(.*?)</ix:nonFraction>`)
+	reAttr      = regexp.MustCompile(`([\w:-]+)\s*=\s*"([^"]*)"`)
+	reTag       = regexp.MustCompile(`<[^>]*>`)
+)
+
+func parseContexts(doc string) map[string]xbrlContext {
+	ctxs := make(map[string]xbrlContext)
+	for _, m := range reContext.FindAllStringSubmatch(doc, -1) {
+		c := xbrlContext{dimension: reSegment.MatchString(m[2])}
+		if v := reInstant.FindStringSubmatch(m[2]); v != nil {
+			c.end = v[1]
+		} else {
+			if v := reStartDate.FindStringSubmatch(m[2]); v != nil {
+				c.start = v[1]
+			}
+			if v := reEndDate.FindStringSubmatch(m[2]); v != nil {
+				c.end = v[1]
+			}
+		}
+		ctxs[m[1]] = c
+	}
+	return ctxs
+}
+
+// parseFactValue applies the ix transformation rules that matter for
+// numbers: display format, scale and sign.
+func parseFactValue(raw string, attrs map[string]string) (float64, bool) {
+	text := strings.TrimSpace(reTag.ReplaceAllString(raw, ""))
+	format := attrs["format"]
+	switch {
+	case strings.Contains(format, "zero") || text == "-" || text == "—":
+		return 0, true
+	case strings.Contains(format, "comma-decimal") || strings.Contains(format, "numcommadecimal"):
+		text = strings.NewReplacer(".", "", " ", "", ",", ".").Replace(text)
+	default:
+		text = strings.NewReplacer(",", "", " ", "").Replace(text)
+	}
+	v, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, false
+	}
+	if scale, err := strconv.Atoi(attrs["scale"]); err == nil {
+		v *= math.Pow10(scale)
+	}
+	if attrs["sign"] == "-" {
+		v = -v
+	}
+	return v, true
+}
+
+// ExtractFinancials groups the iXBRL facts of a document by statement. It
+// returns nil when the document carries no usable iXBRL.
+func ExtractFinancials(doc string) Financials {
+	ctxs := parseContexts(doc)
+	if len(ctxs) == 0 {
+		return nil
+	}
+
+	wanted := make(map[string]string)
+	for stmt, concepts := range statementConcepts {
+		for _, c := range concepts {
+			wanted[c] = stmt
+		}
+	}
+
+	fin := make(Financials)
+	seen := make(map[string]bool)
+	for _, m := range reNonFrac.FindAllStringSubmatch(doc, -1) {
+		attrs := make(map[string]string)
+		for _, a := range reAttr.FindAllStringSubmatch(m[1], -1) {
+			attrs[a[1]] = a[2]
+		}
+		name := attrs["name"]
+		stmt, ok := wanted[name]
+		if !ok {
+			continue
+		}
+		ctx, ok := ctxs[attrs["contextRef"]]
+		if !ok || ctx.dimension || ctx.end == "" {
+			continue
+		}
+		// The same fact is often tagged in several places in the document.
+		key := name + "|" + attrs["contextRef"]
+		if seen[key] {
+			continue
+		}
+		v, ok := parseFactValue(m[2], attrs)
+		if !ok {
+			continue
+		}
+		seen[key] = true
+		if fin[stmt] == nil {
+			fin[stmt] = make(map[string][]PeriodValue)
+		}
+		fin[stmt][name] = append(fin[stmt][name], PeriodValue{
+			Start: ctx.start, End: ctx.end, Value: v, Unit: strings.ToUpper(attrs["unitRef"]),
+		})
+	}
+	if len(fin) == 0 {
+		return nil
+	}
+
+	for _, concepts := range fin {
+		for _, vals := range concepts {
+			sort.SliceStable(vals, func(i, j int) bool {
+				if vals[i].End != vals[j].End {
+					return vals[i].End > vals[j].End
+				}
+				return vals[i].Start > vals[j].Start
+			})
+		}
+	}
+	return fin
+}
+
+// writeFinancials writes the statements as JSON next to the filing. Documents
+// without iXBRL are skipped silently.
+func writeFinancials(fsys FS, doc, filename string) error {
+	fin := ExtractFinancials(doc)
+	if fin == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(fin, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsys.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"edgarv2/edgar"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// facts: structured XBRL values from the companyfacts API
+// ──────────────────────────────────────────────────────────────────────────────
+
+var factsOpts struct {
+	concept string
+	latest  string
+	csv     bool
+}
+
+func factsFlags(fs *flag.FlagSet) {
+	fs.StringVar(&factsOpts.concept, "concept", "", "XBRL concept to print, e.g. us-gaap:Revenues (required)")
+	fs.StringVar(&factsOpts.latest, "latest", "", "print only the most recent value of this concept, e.g. us-gaap:Revenues (instead of -concept)")
+	fs.BoolVar(&factsOpts.csv, "csv", false, "print CSV instead of a table")
+}
+
+// factRow is one output line of the facts command.
+type factRow struct {
+	Ticker  string `json:"ticker"`
+	CIK     string `json:"cik"`
+	Concept string `json:"concept"`
+	Unit    string `json:"unit"`
+	edgar.Fact
+}
+
+// runFacts prints one concept for each ticker or CIK, or with -latest only
+// its most recent value. -from and -to filter on the period end date.
+func runFacts(ctx context.Context, args []string) int {
+	if (factsOpts.concept == "") == (factsOpts.latest == "") || len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "%sUsage: %s facts (-concept | -latest) us-gaap:Revenues [-csv] <ticker|CIK>...%s\n", softRed, os.Args[0], reset)
+		return exitSetup
+	}
+	name := factsOpts.concept
+	if factsOpts.latest != "" {
+		name = factsOpts.latest
+	}
+
+	if factsOpts.csv {
+		out = io.Discard
+	}
+
+	var rows []factRow
+	code := exitOK
+	for _, arg := range args {
+		ticker := strings.ToUpper(strings.TrimSpace(arg))
+		co, err := resolveCompany(ctx, ticker)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s%s: %v%s\n", softRed, ticker, err, reset)
+			hintForbidden(err)
+			code = max(code, errExitStatus(err))
+			continue
+		}
+		cf, err := client.CompanyFacts(ctx, co.CIK)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s%s: %v%s\n", softRed, ticker, err, reset)
+			hintForbidden(err)
+			code = max(code, errExitStatus(err))
+			continue
+		}
+		concept, ok := cf.Concept(name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s%s: %s does not report %s%s\n", earthYellow, ticker, cf.EntityName, name, reset)
+			continue
+		}
+		if factsOpts.latest != "" {
+			u, f, ok := concept.Latest(func(f edgar.Fact) bool { return inPeriod(f.End) })
+			if !ok {
+				fmt.Fprintf(os.Stderr, "%s%s: no %s values in the period%s\n", earthYellow, ticker, name, reset)
+				continue
+			}
+			rows = append(rows, factRow{Ticker: ticker, CIK: edgar.PadCIK(co.CIK), Concept: name, Unit: u, Fact: f})
+			period := f.End
+			if f.Start != "" {
+				period = f.Start + " – " + f.End
+			}
+			fmt.Fprintf(out, "%s%-8s%s %s%20s%s %-6s %s  %s(%s, filed %s, %s)%s\n",
+				bold, ticker, reset, aquaBlue, formatValue(f.Value), reset, u, period, bgGray, f.Form, f.Filed, f.Accn, reset)
+			continue
+		}
+
+		units := make([]string, 0, len(concept.Units))
+		for u := range concept.Units {
+			units = append(units, u)
+		}
+		sort.Strings(units)
+		fmt.Fprintf(out, "\n%s%s%s %s(%s)%s %s — %s%s\n", bold, cf.EntityName, reset, bgGray, edgar.PadCIK(co.CIK), reset, name, concept.Label, reset)
+		for _, u := range units {
+			facts := concept.Units[u]
+			edgar.SortFacts(facts)
+			for _, f := range facts {
+				if !inPeriod(f.End) {
+					continue
+				}
+				rows = append(rows, factRow{Ticker: ticker, CIK: edgar.PadCIK(co.CIK), Concept: name, Unit: u, Fact: f})
+				fmt.Fprintf(out, "  %s  %-10s  %s%20s%s %-6s %-6s FY%d %-3s %sfiled %s%s\n",
+					f.End, f.Start, aquaBlue, formatValue(f.Value), reset, u, f.Form, f.FY, f.FP, bgGray, f.Filed, reset)
+			}
+		}
+	}
+
+	switch {
+	case opts.jsonReport:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rows)
+	case factsOpts.csv:
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"ticker", "cik", "concept", "unit", "start", "end", "value", "form", "fy", "fp", "filed", "accession"})
+		for _, r := range rows {
+			w.Write([]string{r.Ticker, r.CIK, r.Concept, r.Unit, r.Start, r.End, formatValue(r.Value), r.Form, strconv.Itoa(r.FY), r.FP, r.Filed, r.Accn})
+		}
+		w.Flush()
+	}
+	return code
+}
+
+// inPeriod applies -from/-to to a period end date.
+func inPeriod(end string) bool {
+	t, err := time.Parse(time.DateOnly, end)
+	if err != nil {
+		return opts.from.IsZero() && opts.to.IsZero()
+	}
+	return !t.Before(opts.from) && (opts.to.IsZero() || !t.After(opts.to))
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
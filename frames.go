@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"edgarv2/edgar"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// frames: one XBRL concept across companies for a single period
+// ──────────────────────────────────────────────────────────────────────────────
+
+var framesOpts struct {
+	concept string
+	unit    string
+	period  string
+}
+
+func framesFlags(fs *flag.FlagSet) {
+	fs.StringVar(&framesOpts.concept, "concept", "", "XBRL concept to compare, e.g. us-gaap:Revenues (required)")
+	fs.StringVar(&framesOpts.unit, "unit", "USD", "unit of measure, e.g. USD or shares")
+	fs.StringVar(&framesOpts.period, "period", "", "frame period as SEC writes it, e.g. CY2023Q1 or CY2023Q4I (overrides -year)")
+}
+
+// frameRow is one output line of the frames command.
+type frameRow struct {
+	Rank int    `json:"rank"`
+	CIK  string `json:"cik"`
+	edgar.FrameValue
+}
+
+// runFrames prints the frame ranked by value, largest first. Tickers or CIKs
+// given as arguments restrict the table to those companies; -limit keeps the
+// top rows only.
+func runFrames(ctx context.Context, args []string) int {
+	// -year is the global flag; here it names the calendar year.
+	period := framesOpts.period
+	if period == "" && len(opts.years) == 1 {
+		period = fmt.Sprintf("CY%d", opts.years[0])
+	}
+	if framesOpts.concept == "" || period == "" {
+		fmt.Fprintf(os.Stderr, "%sUsage: %s frames -concept us-gaap:Revenues -year 2023 [-unit USD] [<ticker|CIK>...]%s\n", softRed, os.Args[0], reset)
+		return exitSetup
+	}
+
+	var want map[int]bool
+	code := exitOK
+	if len(args) > 0 {
+		want = make(map[int]bool)
+		for _, arg := range args {
+			ticker := strings.ToUpper(strings.TrimSpace(arg))
+			co, err := resolveCompany(ctx, ticker)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s%s: %v%s\n", softRed, ticker, err, reset)
+				hintForbidden(err)
+				code = max(code, errExitStatus(err))
+				continue
+			}
+			want[co.CIK] = true
+		}
+		if len(want) == 0 {
+			return code
+		}
+	}
+
+	fr, err := client.Frame(ctx, framesOpts.concept, framesOpts.unit, period)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sFrame failed: %v%s\n", softRed, err, reset)
+		hintForbidden(err)
+		return errExitStatus(err)
+	}
+	values := fr.Data
+	if want != nil {
+		values = values[:0:0]
+		for _, v := range fr.Data {
+			if want[v.CIK] {
+				values = append(values, v)
+			}
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Value > values[j].Value })
+	if opts.limit > 0 && len(values) > opts.limit {
+		values = values[:opts.limit]
+	}
+
+	rows := make([]frameRow, len(values))
+	for i, v := range values {
+		rows[i] = frameRow{Rank: i + 1, CIK: edgar.PadCIK(v.CIK), FrameValue: v}
+	}
+	if opts.jsonReport {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rows)
+	}
+
+	fmt.Fprintf(out, "\n%s%s%s %s(%s, %s)%s — %s\n", bold, framesOpts.concept, reset, bgGray, period, fr.Unit, reset, fr.Label)
+	if len(rows) == 0 {
+		fmt.Fprintln(out, earthYellow+"No values."+reset)
+	}
+	for _, r := range rows {
+		fmt.Fprintf(out, "%s%4d%s  %s  %s%20s%s  %s  %s%s%s\n",
+			aquaBlue, r.Rank, reset, r.CIK, forestGreen, formatValue(r.Value), reset, r.End, bgGray, r.EntityName, reset)
+	}
+	return code
+}
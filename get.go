@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"edgarv2/edgar"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// get: one filing by accession number
+// ──────────────────────────────────────────────────────────────────────────────
+
+var getOpts struct {
+	accession string
+	doc       string
+	stdout    bool
+}
+
+func getFlags(fs *flag.FlagSet) {
+	fs.StringVar(&getOpts.accession, "accession", "", "accession number of the filing, e.g. 0000320193-24-000123 (required)")
+	fs.StringVar(&getOpts.doc, "doc", "", "file inside the filing to save instead of its primary document, e.g. ex21.htm")
+	fs.BoolVar(&getOpts.stdout, "stdout", false, "write the converted filing to stdout instead of a file, with nothing else, for piping into less or grep")
+}
+
+// runGet downloads the filing named by -accession for the company given by
+// -cik or a ticker argument, bypassing the filing list and its -limit, so
+// filings of any age can be fetched. -format, -exhibits and the other
+// download flags apply as usual. With -stdout the filing is written to
+// stdout, nothing is saved and no skip check is made.
+func runGet(ctx context.Context, args []string) int {
+	company := ""
+	switch {
+	case opts.ciks != "" && len(args) == 0 && !strings.Contains(opts.ciks, ","):
+		company = "CIK:" + strings.TrimSpace(opts.ciks)
+	case opts.ciks == "" && len(args) == 1:
+		company = strings.ToUpper(strings.TrimSpace(args[0]))
+	}
+	if company == "" || getOpts.accession == "" {
+		fmt.Fprintf(os.Stderr, "%sUsage: %s get -accession 0000320193-24-000123 [-doc name.htm] [-stdout] (-cik 320193 | <ticker>)%s\n", softRed, os.Args[0], reset)
+		return exitSetup
+	}
+	if getOpts.stdout {
+		if opts.dryRun || opts.exhibits || opts.jsonReport || opts.jsonLines || opts.summaryJSON {
+			fmt.Fprintf(os.Stderr, "%s-stdout cannot be combined with -dry-run, -exhibits, -json, -jsonl or -summary-json%s\n", softRed, reset)
+			return exitSetup
+		}
+		out = io.Discard
+	}
+
+	co, err := resolveCompany(ctx, company)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s%s: %v%s\n", softRed, company, err, reset)
+		hintForbidden(err)
+		return errExitStatus(err)
+	}
+	f, err := client.FilingByAccession(ctx, co.CIK, getOpts.accession)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s%v%s\n", softRed, err, reset)
+		hintForbidden(err)
+		return errExitStatus(err)
+	}
+	f.Company = co
+	if getOpts.doc != "" {
+		f.Document = getOpts.doc
+	}
+
+	name := co.Ticker
+	if name == "" {
+		name = "CIK" + edgar.PadCIK(co.CIK)
+	}
+	if getOpts.stdout {
+		data, err := client.Render(ctx, f, downloadOptions())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sError: %v%s\n", softRed, err, reset)
+			hintForbidden(err)
+			return errExitStatus(err)
+		}
+		if _, err := os.Stdout.Write(data); err != nil {
+			return exitFailed
+		}
+		return exitOK
+	}
+	dir := filepath.Join(opts.outputDir, "filings_"+name)
+	path := downloadOptions().Path(dir, f)
+	fmt.Fprintf(out, "%s%s%s %s  %s%s%s\n", aquaBlue, f.Form, reset, filingDates(f), bgGray, f.Accession, reset)
+	printSuperseded([]edgar.Filing{f})
+	if opts.dryRun {
+		fmt.Fprintf(out, "%sDry run: would be saved as %s%s\n", earthYellow, path, reset)
+		return exitOK
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError: %v%s\n", softRed, err, reset)
+		return exitFailed
+	}
+
+	res := FilingResult{Form: f.Form, Date: f.FilingDate, Accession: f.Accession, Accepted: f.Accepted, Path: path, Status: "processed", SupersededBy: f.SupersededBy}
+	code := exitOK
+	n, err := client.Download(ctx, f, dir, downloadOptions())
+	switch {
+	case errors.Is(err, edgar.ErrFileExists):
+		res.Status = "skipped"
+		fmt.Fprintf(out, "%sAlready downloaded: %s%s\n", bgGray, path, reset)
+	case err != nil:
+		res.Status, res.Error = "failed", err.Error()
+		code = exitFailed
+		fmt.Fprintf(os.Stderr, "%sError: %v%s\n", softRed, err, reset)
+		hintForbidden(err)
+	default:
+		res.Bytes = n
+		fmt.Fprintf(out, "%sSaved %s (%d bytes)%s\n", forestGreen, path, n, reset)
+	}
+	if opts.exhibits && code == exitOK {
+		count, _, err := client.DownloadExhibits(ctx, f, dir, opts.maxDocBytes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sExhibits: %v%s\n", softRed, err, reset)
+			code = exitFailed
+		}
+		fmt.Fprintf(out, "%sExhibit files downloaded: %s%d%s\n", bgGray, aquaBlue, count, reset)
+	}
+
+	emitFiling(name, res)
+	if opts.jsonReport {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(res)
+	}
+	return code
+}
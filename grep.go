@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// grep: search the text of filings already on disk
+// ──────────────────────────────────────────────────────────────────────────────
+
+var grepOpts struct {
+	ignoreCase bool
+	fixed      bool
+	context    int
+}
+
+func grepFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&grepOpts.ignoreCase, "ignore-case", false, "match without regard to case")
+	fs.BoolVar(&grepOpts.fixed, "fixed", false, "treat the pattern as a literal string, not a regular expression")
+	fs.IntVar(&grepOpts.context, "context", 0, "lines of context to print around each match")
+}
+
+// grepMatch is one matching line, as printed by -json.
+type grepMatch struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// runGrep searches the .txt files under the given files or directories
+// (default -output-dir) and prints each matching line with its file name and
+// line number. Nothing is fetched from EDGAR.
+func runGrep(ctx context.Context, args []string) int {
+	if len(args) == 0 || grepOpts.context < 0 {
+		fmt.Fprintf(os.Stderr, "%sUsage: %s grep [-ignore-case] [-fixed] [-context N] <pattern> [dir|file]...%s\n", softRed, os.Args[0], reset)
+		return exitSetup
+	}
+	pattern := args[0]
+	if grepOpts.fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if grepOpts.ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sBad pattern: %v%s\n", softRed, err, reset)
+		return exitSetup
+	}
+	roots := args[1:]
+	if len(roots) == 0 {
+		roots = []string{opts.outputDir}
+	}
+
+	matches := []grepMatch{}
+	files := 0
+	code := exitOK
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.IsDir() || !strings.HasSuffix(path, ".txt") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			found := grepText(path, string(data), re)
+			if len(found) > 0 {
+				files++
+				matches = append(matches, found...)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s%v%s\n", softRed, err, reset)
+			code = exitFailed
+		}
+	}
+
+	if opts.jsonReport {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(matches)
+	}
+	if len(matches) == 0 {
+		fmt.Fprintln(out, earthYellow+"No matches."+reset)
+		return code
+	}
+	fmt.Fprintf(out, "%s%d matching line(s) in %d file(s)%s\n", bgGray, len(matches), files, reset)
+	return code
+}
+
+// grepText prints the lines of one file that match re, with -context lines
+// around them, and returns the matches. Overlapping context is printed once;
+// separate groups are divided by "--" as in grep.
+func grepText(path, text string, re *regexp.Regexp) []grepMatch {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	var matches []grepMatch
+	printed := -1 // index of the last line printed
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		matches = append(matches, grepMatch{Path: path, Line: i + 1, Text: line})
+		if len(matches) == 1 {
+			fmt.Fprintf(out, "\n%s%s%s\n", aquaBlue, path, reset)
+		}
+		from := max(i-grepOpts.context, printed+1)
+		if grepOpts.context > 0 && printed >= 0 && from > printed+1 {
+			fmt.Fprintln(out, bgGray+"--"+reset)
+		}
+		for j := from; j < i; j++ {
+			fmt.Fprintf(out, "%s%6d-%s %s\n", bgGray, j+1, reset, lines[j])
+		}
+		fmt.Fprintf(out, "%s%6d:%s %s\n", forestGreen, i+1, reset, highlight(line, re))
+		printed = i
+		// Trailing context stops short of the next match, which prints itself.
+		for j := i + 1; j <= i+grepOpts.context && j < len(lines) && !re.MatchString(lines[j]); j++ {
+			fmt.Fprintf(out, "%s%6d-%s %s\n", bgGray, j+1, reset, lines[j])
+			printed = j
+		}
+	}
+	return matches
+}
+
+// highlight colors every match of re in line.
+func highlight(line string, re *regexp.Regexp) string {
+	if earthYellow == "" {
+		return line
+	}
+	return re.ReplaceAllStringFunc(line, func(m string) string { return earthYellow + bold + m + reset })
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"edgarv2/edgar"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// -list-forms: which form types a company files
+// ──────────────────────────────────────────────────────────────────────────────
+
+// formList is the -json output of -list-forms for one ticker.
+type formList struct {
+	Ticker string              `json:"ticker"`
+	CIK    string              `json:"cik"`
+	Forms  []edgar.FormSummary `json:"forms,omitempty"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// listForms prints, per ticker, how often each form type appears among its
+// recent filings and when it was last filed. Nothing is downloaded.
+func listForms(ctx context.Context, tickers []string) int {
+	var lists []formList
+	code := exitOK
+	for _, ticker := range tickers {
+		if ctx.Err() != nil {
+			return exitFailed
+		}
+		l := formList{Ticker: ticker}
+		co, err := resolveCompany(ctx, ticker)
+		if err == nil {
+			l.CIK = edgar.PadCIK(co.CIK)
+			l.Forms, err = client.RecentForms(ctx, co.CIK)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s%s: %v%s\n", softRed, ticker, err, reset)
+			hintForbidden(err)
+			l.Error = err.Error()
+			code = max(code, errExitStatus(err))
+		}
+		lists = append(lists, l)
+		if err != nil {
+			continue
+		}
+
+		fmt.Fprintf(out, "\n%s%s%s %s(%s)%s\n", bold, ticker, reset, bgGray, l.CIK, reset)
+		for _, f := range l.Forms {
+			color := aquaBlue
+			if edgar.IsAmendment(f.Form) {
+				color = earthYellow
+			}
+			fmt.Fprintf(out, "  %s%-12s%s %5d  %slatest %s%s\n", color, f.Form, reset, f.Count, bgGray, f.Latest, reset)
+		}
+	}
+	if opts.jsonReport {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(lists)
+	}
+	return code
+}
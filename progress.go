@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"edgarv2/edgar"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Terminal progress
+// ──────────────────────────────────────────────────────────────────────────────
+
+var spinners = []string{" ", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+
+// barProgress is the spinner and progress bar of a ticker's downloads. It
+// also records each finished filing in res and the log file.
+type barProgress struct {
+	ctx    context.Context
+	ticker string
+	res    *TickerResult
+	// owner, if set, is the ticker and result of each filing instead, when
+	// one bar follows the downloads of several tickers (-shuffle).
+	owner func(r edgar.FileResult) (string, *TickerResult)
+
+	// mu serializes writes to the progress line; retry notices come from
+	// worker goroutines.
+	mu   sync.Mutex
+	line string
+}
+
+func (p *barProgress) OnStart(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.line = fmt.Sprintf(" %s [%s]   0%% ", spinners[0], strings.Repeat(" ", barWidth))
+	fmt.Fprint(out, "\r\033[K"+p.line)
+}
+
+func (p *barProgress) OnFile(r edgar.FileResult, done, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	it, n, err, ticker, res := r.Filing, r.Bytes, r.Err, p.ticker, p.res
+	if p.owner != nil {
+		ticker, res = p.owner(r)
+	}
+	res.Exhibits += r.Exhibits
+
+	fr := FilingResult{Form: it.Form, Date: it.FilingDate, Accession: it.Accession, Accepted: it.Accepted, Path: r.Path, Bytes: n, SupersededBy: it.SupersededBy}
+	switch {
+	case errors.Is(err, edgar.ErrFileExists):
+		res.Skipped++
+		fr.Status = "skipped"
+		logFiling(ticker, it, fr.Status, n, r.Elapsed, nil)
+	case errors.Is(err, edgar.ErrTooLarge):
+		res.TooLarge++
+		fr.Status, fr.Error = "too_large", err.Error()
+		fmt.Fprintf(out, "\r\033[K%sSkipped %s (%s): %v%s\n", earthYellow, it.Form, it.FilingDate, err, reset)
+		logFiling(ticker, it, fr.Status, n, r.Elapsed, err)
+	case errors.Is(err, edgar.ErrFileTimeout):
+		res.TimedOut++
+		fr.Status, fr.Error = "timed_out", err.Error()
+		fmt.Fprintf(out, "\r\033[K%sTimed out %s (%s): %v%s\n", softRed, it.Form, it.FilingDate, err, reset)
+		logFiling(ticker, it, fr.Status, n, r.Elapsed, err)
+	case err != nil && p.ctx.Err() != nil:
+		res.Canceled++
+		fr.Status, fr.Error = "canceled", err.Error()
+		logFiling(ticker, it, fr.Status, n, r.Elapsed, err)
+	case err != nil:
+		res.Failed++
+		fr.Status, fr.Error = "failed", err.Error()
+		fmt.Fprintf(out, "\r\033[K%sError %s (%s): %v%s\n", softRed, it.Form, it.FilingDate, err, reset)
+		hintForbidden(err)
+		logFiling(ticker, it, fr.Status, n, r.Elapsed, err)
+	default:
+		res.Processed++
+		res.Bytes += n
+		fr.Status = "processed"
+		logFiling(ticker, it, fr.Status, n, r.Elapsed, nil)
+	}
+	res.Filings = append(res.Filings, fr)
+	emitFiling(ticker, fr)
+
+	percent := float64(done) / float64(total)
+	filled := int(percent * float64(barWidth))
+	bar := strings.Repeat("■", filled) + strings.Repeat(" ", barWidth-filled)
+	label := it.Form
+	if p.owner != nil {
+		label = ticker + " " + label
+	}
+	p.line = fmt.Sprintf(" %s %s (%s) [%s%s%s] %3.0f%% ",
+		spinners[(done-1)%len(spinners)], label, filingDates(it), forestGreen, bar, reset, percent*100)
+	fmt.Fprint(out, "\r\033[K"+p.line)
+}
+
+func (p *barProgress) OnDone(done, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ctx.Err() != nil {
+		fmt.Fprintf(out, "\r\033[K%s ✗ stopped after %d of %d file(s)%s\n", softRed, done, total, reset)
+	} else {
+		fmt.Fprintf(out, "\r\033[K ✓ [%s] 100%% \n", strings.Repeat("■", barWidth))
+	}
+}
+
+// onRetry is the RetryHook that shows a pending retry after the bar.
+func (p *barProgress) onRetry(delay time.Duration, status int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	reason := "network error"
+	if status != 0 {
+		reason = strconv.Itoa(status)
+	}
+	fmt.Fprintf(out, "\r\033[K%s%sretrying in %s (%s)%s", p.line, earthYellow, delay, reason, reset)
+}
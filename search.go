@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"edgarv2/edgar"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// search: EDGAR full-text search
+// ──────────────────────────────────────────────────────────────────────────────
+
+var searchDownload bool
+
+func searchFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&searchDownload, "download", false, "also download every matching document into -output-dir")
+}
+
+// runSearch lists the documents matching a full-text query, honoring -forms,
+// -from, -to and -limit, and optionally downloads them.
+func runSearch(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "%sUsage: %s search [flags] <query>%s\n", softRed, os.Args[0], reset)
+		return exitSetup
+	}
+	query := strings.Join(args, " ")
+
+	fmt.Fprintf(out, "%sSearching for %s%s%s...%s\n", bgGray, aquaBlue, query, bgGray, reset)
+	hits, err := client.Search(ctx, query, edgar.SearchOptions{
+		Forms: opts.forms.list(),
+		From:  opts.from,
+		To:    opts.to,
+		Limit: opts.limit,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sSearch failed: %v%s\n", softRed, err, reset)
+		hintForbidden(err)
+		if len(hits) == 0 {
+			return errExitStatus(err)
+		}
+	}
+
+	if opts.jsonReport {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(hits)
+	}
+	if len(hits) == 0 {
+		fmt.Fprintln(out, earthYellow+"No matches."+reset)
+		return exitOK
+	}
+	for i, h := range hits {
+		fmt.Fprintf(out, "%s%3d%s  %s  %-8s %s  %s%s%s\n", aquaBlue, i+1, reset, h.FilingDate, h.Form, h.Accession, bgGray, h.Company, reset)
+	}
+	if !searchDownload {
+		return exitOK
+	}
+
+	failed := 0
+	for _, h := range hits {
+		if ctx.Err() != nil {
+			return exitFailed
+		}
+		dir := filepath.Join(opts.outputDir, "filings_CIK"+edgar.PadCIK(h.CIK))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "%sError: %v%s\n", softRed, err, reset)
+			return exitFailed
+		}
+		_, err := client.Download(ctx, h.Filing(), dir, downloadOptions())
+		switch {
+		case errors.Is(err, edgar.ErrFileExists):
+			fmt.Fprintf(out, "%sSkipped %s (%s): already downloaded%s\n", bgGray, h.Form, h.Accession, reset)
+		case err != nil:
+			failed++
+			fmt.Fprintf(os.Stderr, "%sError %s (%s): %v%s\n", softRed, h.Form, h.Accession, err, reset)
+			hintForbidden(err)
+		default:
+			fmt.Fprintf(out, "%sSaved %s (%s) in %s%s\n", forestGreen, h.Form, h.Accession, dir, reset)
+		}
+	}
+	if failed > 0 {
+		return exitFailed
+	}
+	return exitOK
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"edgarv2/edgar"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// whois: the tickers of a CIK
+// ──────────────────────────────────────────────────────────────────────────────
+
+// whoisResult is the -json output of whois for one CIK.
+type whoisResult struct {
+	CIK     string   `json:"cik"`
+	Title   string   `json:"title,omitempty"`
+	Tickers []string `json:"tickers,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// runWhois prints the company title and every ticker listed for each CIK
+// given by -cik or as an argument, from the same cached company_tickers.json
+// the ticker lookup uses.
+func runWhois(ctx context.Context, args []string) int {
+	var ciks []int
+	for _, arg := range append(strings.Split(opts.ciks, ","), args...) {
+		if strings.TrimSpace(arg) == "" {
+			continue
+		}
+		cik, ok := parseCIKArg(arg)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%sNot a CIK: %q%s\n", softRed, arg, reset)
+			return exitSetup
+		}
+		ciks = append(ciks, cik)
+	}
+	if len(ciks) == 0 {
+		fmt.Fprintf(os.Stderr, "%sUsage: %s whois (-cik 320193 | <CIK>...)%s\n", softRed, os.Args[0], reset)
+		return exitSetup
+	}
+
+	var results []whoisResult
+	code := exitOK
+	for _, cik := range ciks {
+		r := whoisResult{CIK: edgar.PadCIK(cik)}
+		companies, err := client.TickersForCIK(ctx, cik)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sCIK %s: %v%s\n", softRed, r.CIK, err, reset)
+			hintForbidden(err)
+			r.Error = err.Error()
+			code = max(code, errExitStatus(err))
+			results = append(results, r)
+			continue
+		}
+		r.Title = companies[0].Title
+		for _, co := range companies {
+			r.Tickers = append(r.Tickers, co.Ticker)
+		}
+		results = append(results, r)
+		fmt.Fprintf(out, "%s%s%s %s(%s)%s\n", bold, r.Title, reset, bgGray, r.CIK, reset)
+		fmt.Fprintf(out, "  %s%s%s\n", aquaBlue, strings.Join(r.Tickers, " "), reset)
+	}
+	if opts.jsonReport {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+	}
+	return code
+}